@@ -5,12 +5,11 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
 	"os"
 	"os/signal"
 
-	"github.com/islo-labs/double-agent/internal/config"
 	"github.com/islo-labs/double-agent/internal/engine"
+	"github.com/islo-labs/double-agent/internal/pluginstore"
 )
 
 func main() {
@@ -21,35 +20,118 @@ func main() {
 }
 
 func run() error {
-	configFile := flag.String("config", "double.hcl", "path to config file")
-	flag.Parse()
-
-	args := flag.Args()
-	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "usage: double run [-config double.hcl]")
-		os.Exit(1)
+	if len(os.Args) < 2 {
+		return usageErr()
 	}
-	cmd := args[0]
-	if cmd != "run" {
-		return fmt.Errorf("unknown command: %q (expected 'run')", cmd)
+	switch os.Args[1] {
+	case "run":
+		return runService(os.Args[2:])
+	case "plugin":
+		return runPlugin(os.Args[2:])
+	default:
+		return usageErr()
 	}
+}
 
-	cfg, err := config.Load(*configFile)
-	if err != nil {
-		return fmt.Errorf("loading config: %w", err)
+func usageErr() error {
+	fmt.Fprintln(os.Stderr, "usage: double run [-config double.hcl]")
+	fmt.Fprintln(os.Stderr, "       double plugin install <ref> [-alias name] -plugin-dir dir")
+	fmt.Fprintln(os.Stderr, "       double plugin ls -plugin-dir dir")
+	fmt.Fprintln(os.Stderr, "       double plugin rm <alias> -plugin-dir dir")
+	fmt.Fprintln(os.Stderr, "       double plugin enable|disable <alias> -plugin-dir dir")
+	fmt.Fprintln(os.Stderr, "       double plugin push <dir> <ref>")
+	os.Exit(1)
+	return nil
+}
+
+func runService(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	configFile := fs.String("config", "double.hcl", "path to config file")
+	fs.Parse(args)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	// Bootstrap owns config loading itself, so it can re-read
+	// configFile on SIGHUP and re-exec this same invocation on SIGUSR2.
+	b := &engine.Bootstrap{ConfigPath: *configFile}
+	return b.Run(ctx)
+}
+
+// runPlugin dispatches the "double plugin ..." subcommands, which manage
+// third-party plugins installed via internal/pluginstore.
+func runPlugin(args []string) error {
+	if len(args) == 0 {
+		return usageErr()
 	}
-	if len(cfg.Services) == 0 {
-		return fmt.Errorf("no services defined in %s", *configFile)
+	verb, rest := args[0], args[1:]
+
+	if verb == "push" {
+		fs := flag.NewFlagSet("plugin push", flag.ExitOnError)
+		fs.Parse(rest)
+		if fs.NArg() != 2 {
+			return fmt.Errorf("usage: double plugin push <dir> <ref>")
+		}
+		if err := pluginstore.Push(fs.Arg(0), fs.Arg(1)); err != nil {
+			return err
+		}
+		fmt.Printf("pushed %s\n", fs.Arg(1))
+		return nil
 	}
 
-	eng, err := engine.New(cfg)
+	fs := flag.NewFlagSet("plugin "+verb, flag.ExitOnError)
+	pluginDir := fs.String("plugin-dir", "", "directory plugins are installed into")
+	alias := fs.String("alias", "", "local alias for the installed plugin")
+	fs.Parse(rest)
+
+	if *pluginDir == "" {
+		return fmt.Errorf("-plugin-dir is required")
+	}
+	store, err := pluginstore.New(*pluginDir)
 	if err != nil {
 		return err
 	}
 
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer cancel()
-
-	log.Printf("DoubleAgent starting with %d service(s)", len(cfg.Services))
-	return eng.Run(ctx)
+	switch verb {
+	case "install":
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: double plugin install <ref> [-alias name] -plugin-dir dir")
+		}
+		entry, err := store.Install(fs.Arg(0), *alias)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("installed %s as %q (%s)\n", entry.Ref, entry.Alias, entry.Digest)
+		return nil
+	case "ls":
+		entries, err := store.List()
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			status := "enabled"
+			if !e.Enabled {
+				status = "disabled"
+			}
+			fmt.Printf("%s\t%s\t%s\t%s\n", e.Alias, e.Ref, e.Digest, status)
+		}
+		return nil
+	case "rm":
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: double plugin rm <alias> -plugin-dir dir")
+		}
+		return store.Remove(fs.Arg(0))
+	case "enable":
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: double plugin enable <alias> -plugin-dir dir")
+		}
+		return store.Enable(fs.Arg(0))
+	case "disable":
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: double plugin disable <alias> -plugin-dir dir")
+		}
+		return store.Disable(fs.Arg(0))
+	default:
+		return usageErr()
+	}
 }
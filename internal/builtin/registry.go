@@ -4,11 +4,15 @@ package builtin
 import (
 	"github.com/islo-labs/double-agent/pkg/sdk"
 	"github.com/islo-labs/double-agent/plugins/github"
+	"github.com/islo-labs/double-agent/plugins/gitlab"
 	"github.com/islo-labs/double-agent/plugins/jira"
+	"github.com/islo-labs/double-agent/plugins/sourcehut"
 )
 
 // Registry maps plugin type names to their constructor functions.
 var Registry = map[string]func() sdk.Plugin{
-	"github": github.New,
-	"jira":   jira.New,
+	"github":    github.New,
+	"jira":      jira.New,
+	"gitlab":    gitlab.New,
+	"sourcehut": sourcehut.New,
 }
@@ -8,9 +8,11 @@ import (
 )
 
 func TestRegistry_ContainsExpectedPlugins(t *testing.T) {
-	assert.Len(t, Registry, 2)
+	assert.Len(t, Registry, 4)
 	assert.Contains(t, Registry, "github")
 	assert.Contains(t, Registry, "jira")
+	assert.Contains(t, Registry, "gitlab")
+	assert.Contains(t, Registry, "sourcehut")
 }
 
 func TestRegistry_GitHubConstructor(t *testing.T) {
@@ -29,6 +31,22 @@ func TestRegistry_JiraConstructor(t *testing.T) {
 	assert.Equal(t, "jira", p.Info().Name)
 }
 
+func TestRegistry_GitLabConstructor(t *testing.T) {
+	newFn, ok := Registry["gitlab"]
+	require.True(t, ok)
+	p := newFn()
+	require.NotNil(t, p)
+	assert.Equal(t, "gitlab", p.Info().Name)
+}
+
+func TestRegistry_SourcehutConstructor(t *testing.T) {
+	newFn, ok := Registry["sourcehut"]
+	require.True(t, ok)
+	p := newFn()
+	require.NotNil(t, p)
+	assert.Equal(t, "sourcehut", p.Info().Name)
+}
+
 func TestRegistry_UnknownType(t *testing.T) {
 	_, ok := Registry["unknown"]
 	assert.False(t, ok)
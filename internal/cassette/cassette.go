@@ -0,0 +1,124 @@
+// Package cassette implements a minimal VCR-style fixture format for
+// recording real upstream API traffic and replaying it later, so a
+// service can be seeded from what a real vendor actually said instead
+// of a hand-written fake response.
+package cassette
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Header is a recorded request/response header, using a slice instead
+// of map[string][]string so the on-disk YAML stays stable and readable
+// across repeated recordings of the same interaction.
+type Header struct {
+	Name   string   `yaml:"name"`
+	Values []string `yaml:"values"`
+}
+
+// Request is the recorded half of an Interaction that identifies it.
+type Request struct {
+	Method   string   `yaml:"method"`
+	Path     string   `yaml:"path"`
+	BodyHash string   `yaml:"body_hash,omitempty"`
+	Headers  []Header `yaml:"headers,omitempty"`
+}
+
+// Response is the recorded half of an Interaction that gets replayed.
+type Response struct {
+	Status  int      `yaml:"status"`
+	Headers []Header `yaml:"headers,omitempty"`
+	Body    string   `yaml:"body,omitempty"`
+}
+
+// Interaction is one recorded request/response pair.
+type Interaction struct {
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+}
+
+// Cassette is the on-disk fixture file backing a record/replay service,
+// fixtures/<service>.yaml, keyed by method+path+body-hash so recording
+// the same request twice overwrites rather than duplicates an entry.
+type Cassette struct {
+	Path string `yaml:"-"`
+
+	mu           sync.Mutex
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Load reads the cassette at path, returning an empty Cassette if the
+// file doesn't exist yet — the common case the first time a service
+// records.
+func Load(path string) (*Cassette, error) {
+	c := &Cassette{Path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Find returns the interaction matching method, path, and body, if any.
+func (c *Cassette) Find(method, path string, body []byte) (Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash := hashBody(body)
+	for _, i := range c.Interactions {
+		if i.Request.Method == method && i.Request.Path == path && i.Request.BodyHash == hash {
+			return i, true
+		}
+	}
+	return Interaction{}, false
+}
+
+// Append records a new interaction, replacing any existing one with the
+// same method+path+body-hash key, and persists the cassette to disk.
+func (c *Cassette) Append(i Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for idx, existing := range c.Interactions {
+		if existing.Request.Method == i.Request.Method &&
+			existing.Request.Path == i.Request.Path &&
+			existing.Request.BodyHash == i.Request.BodyHash {
+			c.Interactions[idx] = i
+			return c.save()
+		}
+	}
+	c.Interactions = append(c.Interactions, i)
+	return c.save()
+}
+
+// save writes the cassette back to Path, creating its directory if
+// needed. Callers must hold mu.
+func (c *Cassette) save() error {
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+		return fmt.Errorf("creating fixtures dir: %w", err)
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("encoding cassette: %w", err)
+	}
+	if err := os.WriteFile(c.Path, data, 0o644); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return nil
+}
+
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])[:16]
+}
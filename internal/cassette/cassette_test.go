@@ -0,0 +1,69 @@
+package cassette
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_MissingFileReturnsEmptyCassette(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, c.Interactions)
+}
+
+func TestCassette_AppendPersistsAndFindRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures", "svc.yaml")
+	c, err := Load(path)
+	require.NoError(t, err)
+
+	i := Interaction{
+		Request:  Request{Method: "GET", Path: "/issues", BodyHash: hashBody(nil)},
+		Response: Response{Status: 200, Body: `{"ok":true}`},
+	}
+	require.NoError(t, c.Append(i))
+
+	got, ok := c.Find("GET", "/issues", nil)
+	require.True(t, ok)
+	assert.Equal(t, `{"ok":true}`, got.Response.Body)
+
+	// A freshly loaded cassette from the same path sees what was persisted.
+	reloaded, err := Load(path)
+	require.NoError(t, err)
+	_, ok = reloaded.Find("GET", "/issues", nil)
+	assert.True(t, ok)
+}
+
+func TestCassette_AppendOverwritesSameKey(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "svc.yaml"))
+	require.NoError(t, err)
+
+	require.NoError(t, c.Append(Interaction{
+		Request:  Request{Method: "GET", Path: "/x", BodyHash: hashBody(nil)},
+		Response: Response{Status: 200, Body: "first"},
+	}))
+	require.NoError(t, c.Append(Interaction{
+		Request:  Request{Method: "GET", Path: "/x", BodyHash: hashBody(nil)},
+		Response: Response{Status: 200, Body: "second"},
+	}))
+
+	assert.Len(t, c.Interactions, 1)
+	got, ok := c.Find("GET", "/x", nil)
+	require.True(t, ok)
+	assert.Equal(t, "second", got.Response.Body)
+}
+
+func TestCassette_FindDistinguishesByBody(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "svc.yaml"))
+	require.NoError(t, err)
+
+	require.NoError(t, c.Append(Interaction{
+		Request:  Request{Method: "POST", Path: "/x", BodyHash: hashBody([]byte("a"))},
+		Response: Response{Status: 200, Body: "for-a"},
+	}))
+
+	_, ok := c.Find("POST", "/x", []byte("b"))
+	assert.False(t, ok, "a different request body must not match a recorded interaction")
+}
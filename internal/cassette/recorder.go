@@ -0,0 +1,106 @@
+package cassette
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Recorder proxies requests to Upstream, scrubs and records each
+// interaction into Cassette, and rewrites Upstream's own host back to
+// the request's Host in the response body and Location header, so the
+// caller sees a self-consistent local service rather than a mix of
+// local and upstream URLs.
+type Recorder struct {
+	Upstream string
+	Cassette *Cassette
+	Scrub    []ScrubRule
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	// Propagator, if set, is called on the outgoing proxy request before
+	// it's sent, so an inbound request's trace continues through the
+	// upstream call instead of leaving this hop unlinked.
+	Propagator func(ctx context.Context, req *http.Request)
+}
+
+// ServeHTTP implements http.Handler.
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	client := rec.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"reading request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	proxyReq, err := http.NewRequest(r.Method, rec.Upstream+r.URL.RequestURI(), bytes.NewReader(reqBody))
+	if err != nil {
+		http.Error(w, `{"error":"building upstream request"}`, http.StatusBadGateway)
+		return
+	}
+	proxyReq.Header = r.Header.Clone()
+	if rec.Propagator != nil {
+		rec.Propagator(r.Context(), proxyReq)
+	}
+
+	resp, err := client.Do(proxyReq)
+	if err != nil {
+		http.Error(w, `{"error":"upstream request failed"}`, http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, `{"error":"reading upstream response"}`, http.StatusBadGateway)
+		return
+	}
+
+	localHost := "http://" + r.Host
+	localized := bytes.ReplaceAll(respBody, []byte(rec.Upstream), []byte(localHost))
+	if loc := resp.Header.Get("Location"); loc != "" {
+		resp.Header.Set("Location", strings.ReplaceAll(loc, rec.Upstream, localHost))
+	}
+
+	rec.record(r.Method, r.URL.Path, reqBody, r.Header, resp, localized)
+
+	for name, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(name, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(localized)
+}
+
+func (rec *Recorder) record(method, path string, reqBody []byte, reqHeader http.Header, resp *http.Response, respBody []byte) {
+	i := Interaction{
+		Request: Request{
+			Method:   method,
+			Path:     path,
+			BodyHash: hashBody(reqBody),
+			Headers:  scrubHeaders(toHeaders(reqHeader), rec.Scrub),
+		},
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: scrubHeaders(toHeaders(resp.Header), rec.Scrub),
+			Body:    scrubBody(string(respBody), rec.Scrub),
+		},
+	}
+	// Best effort: a cassette write failure shouldn't fail the proxied
+	// response the caller is already waiting on.
+	_ = rec.Cassette.Append(i)
+}
+
+func toHeaders(h http.Header) []Header {
+	out := make([]Header, 0, len(h))
+	for name, values := range h {
+		out = append(out, Header{Name: name, Values: append([]string{}, values...)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,95 @@
+package cassette
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_ProxiesRecordsAndLocalizesUpstreamHost(t *testing.T) {
+	var upstreamURL string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", upstreamURL+"/issues/1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"self":"` + upstreamURL + `/issues/1"}`))
+	}))
+	defer upstream.Close()
+	upstreamURL = upstream.URL
+
+	c, err := Load(filepath.Join(t.TempDir(), "svc.yaml"))
+	require.NoError(t, err)
+	rec := &Recorder{Upstream: upstream.URL, Cassette: c}
+
+	req := httptest.NewRequest(http.MethodPost, "/issues", strings.NewReader(`{}`))
+	req.Host = "localhost:9999"
+	w := httptest.NewRecorder()
+	rec.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"self":"http://localhost:9999/issues/1"}`, w.Body.String())
+	assert.Equal(t, "http://localhost:9999/issues/1", w.Header().Get("Location"))
+
+	got, ok := c.Find(http.MethodPost, "/issues", []byte("{}"))
+	require.True(t, ok)
+	assert.Equal(t, http.StatusCreated, got.Response.Status)
+}
+
+func TestRecorder_ScrubsAuthorizationHeaderBeforePersisting(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	c, err := Load(filepath.Join(t.TempDir(), "svc.yaml"))
+	require.NoError(t, err)
+	rec := &Recorder{
+		Upstream: upstream.URL,
+		Cassette: c,
+		Scrub:    []ScrubRule{{Headers: []string{"Authorization"}}},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/x", nil)
+	req.Header.Set("Authorization", "Bearer super-secret")
+	rec.ServeHTTP(httptest.NewRecorder(), req)
+
+	got, ok := c.Find(http.MethodGet, "/x", nil)
+	require.True(t, ok)
+	for _, h := range got.Request.Headers {
+		if strings.EqualFold(h.Name, "Authorization") {
+			assert.Equal(t, []string{"REDACTED"}, h.Values)
+		}
+	}
+}
+
+func TestReplayer_ServesRecordedInteraction(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "svc.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, c.Append(Interaction{
+		Request:  Request{Method: http.MethodGet, Path: "/issues/1", BodyHash: hashBody(nil)},
+		Response: Response{Status: http.StatusOK, Body: `{"id":1}`, Headers: []Header{{Name: "Content-Type", Values: []string{"application/json"}}}},
+	}))
+
+	rep := &Replayer{Cassette: c}
+	w := httptest.NewRecorder()
+	rep.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/issues/1", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"id":1}`, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+}
+
+func TestReplayer_404sOnNoMatchingInteraction(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "svc.yaml"))
+	require.NoError(t, err)
+	rep := &Replayer{Cassette: c}
+
+	w := httptest.NewRecorder()
+	rep.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
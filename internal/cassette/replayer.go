@@ -0,0 +1,35 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+)
+
+// Replayer serves responses recorded in Cassette and never touches the
+// network: a request with no matching interaction 404s instead of
+// falling through to a live call, so a replaying test fails loudly
+// rather than silently hitting the real vendor.
+type Replayer struct {
+	Cassette *Cassette
+}
+
+// ServeHTTP implements http.Handler.
+func (rep *Replayer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"reading request body"}`, http.StatusBadRequest)
+		return
+	}
+	i, ok := rep.Cassette.Find(r.Method, r.URL.Path, body)
+	if !ok {
+		http.Error(w, `{"error":"no cassette interaction matches request"}`, http.StatusNotFound)
+		return
+	}
+	for _, h := range i.Response.Headers {
+		for _, v := range h.Values {
+			w.Header().Add(h.Name, v)
+		}
+	}
+	w.WriteHeader(i.Response.Status)
+	io.WriteString(w, i.Response.Body)
+}
@@ -0,0 +1,57 @@
+package cassette
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ScrubRule redacts sensitive data from a recorded interaction before it
+// hits disk, so a cassette captured against a real vendor can be
+// committed to a repo without leaking the credentials used to record
+// it.
+type ScrubRule struct {
+	// Headers, if set, redacts these header names (case-insensitive) in
+	// both the request and response.
+	Headers []string
+	// BodyPattern, if set, redacts every match of this pattern in the
+	// request and response bodies.
+	BodyPattern *regexp.Regexp
+	// Replacement is substituted for each redacted value; defaults to
+	// "REDACTED" if empty.
+	Replacement string
+}
+
+func (r ScrubRule) replacement() string {
+	if r.Replacement != "" {
+		return r.Replacement
+	}
+	return "REDACTED"
+}
+
+// scrubHeaders redacts any header named by rules, returning a copy so
+// the caller's own header slice is left untouched.
+func scrubHeaders(headers []Header, rules []ScrubRule) []Header {
+	out := make([]Header, len(headers))
+	copy(out, headers)
+	for _, rule := range rules {
+		for _, name := range rule.Headers {
+			for i := range out {
+				if strings.EqualFold(out[i].Name, name) {
+					out[i].Values = []string{rule.replacement()}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// scrubBody redacts every BodyPattern match in body.
+func scrubBody(body string, rules []ScrubRule) string {
+	for _, rule := range rules {
+		if rule.BodyPattern == nil {
+			continue
+		}
+		body = rule.BodyPattern.ReplaceAllString(body, rule.replacement())
+	}
+	return body
+}
@@ -0,0 +1,42 @@
+package cassette
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrubHeaders_RedactsNamedHeaderCaseInsensitively(t *testing.T) {
+	headers := []Header{
+		{Name: "Authorization", Values: []string{"Bearer secret"}},
+		{Name: "Content-Type", Values: []string{"application/json"}},
+	}
+	rules := []ScrubRule{{Headers: []string{"authorization"}}}
+
+	out := scrubHeaders(headers, rules)
+	assert.Equal(t, []string{"REDACTED"}, out[0].Values)
+	assert.Equal(t, []string{"application/json"}, out[1].Values)
+	assert.Equal(t, []string{"Bearer secret"}, headers[0].Values, "scrubHeaders must not mutate its input")
+}
+
+func TestScrubHeaders_CustomReplacement(t *testing.T) {
+	headers := []Header{{Name: "X-Api-Key", Values: []string{"abc123"}}}
+	rules := []ScrubRule{{Headers: []string{"X-Api-Key"}, Replacement: "***"}}
+
+	out := scrubHeaders(headers, rules)
+	assert.Equal(t, []string{"***"}, out[0].Values)
+}
+
+func TestScrubBody_RedactsPatternMatches(t *testing.T) {
+	rules := []ScrubRule{{BodyPattern: regexp.MustCompile(`"token":"[^"]+"`)}}
+	body := `{"token":"abc123","id":1}`
+
+	got := scrubBody(body, rules)
+	assert.Equal(t, `{REDACTED,"id":1}`, got)
+}
+
+func TestScrubBody_NilPatternIsNoop(t *testing.T) {
+	rules := []ScrubRule{{Headers: []string{"X"}}}
+	assert.Equal(t, "unchanged", scrubBody("unchanged", rules))
+}
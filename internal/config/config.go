@@ -13,16 +13,197 @@ import (
 // Config is the top-level configuration.
 type Config struct {
 	Services []Service `hcl:"service,block"`
+	// PluginDir confines where an external service's command may
+	// resolve to, so a config-supplied command can't launch a binary
+	// outside it. Empty disables confinement.
+	PluginDir string `hcl:"plugin_dir,optional"`
+	// Grants declare the privileges a plugin type is allowed to use,
+	// keyed by type. A service whose type has no matching grant is
+	// unrestricted, for backward compatibility with configs that
+	// predate this block.
+	Grants []Grant `hcl:"grant,block"`
+	// AdminAddr, if set, serves the control-plane endpoints described
+	// by engine.Engine.AdminHandler (GET /plugins, POST
+	// /plugins/{name}/enable, etc.) on their own listener, separate
+	// from every service's own address. Empty disables it.
+	AdminAddr string `hcl:"admin_addr,optional"`
+	// AdminToken, if set, requires "Authorization: Bearer <token>" on
+	// every admin control-plane request. AdminAddr exposes lifecycle
+	// verbs like /plugins/{name}/restart and /service/stop, so leaving
+	// this empty is only appropriate when AdminAddr is itself bound to a
+	// trusted network.
+	AdminToken string `hcl:"admin_token,optional"`
+	// Observability configures request tracing, metrics, and access
+	// logging across every service. Nil disables OTLP export and
+	// /metrics but the engine still creates spans and records metrics
+	// in-process, so the observability.Recorder test helper works even
+	// with no block present.
+	Observability *Observability `hcl:"observability,block"`
+}
+
+// Observability configures the cross-cutting tracing, metrics, and
+// access-log middleware engine.New wraps every service's mux with, e.g.:
+//
+//	observability {
+//	  service_name  = "doubleagent"
+//	  otlp_endpoint = "localhost:4318"
+//	  metrics_addr  = ":9090"
+//	}
+type Observability struct {
+	// ServiceName attributes every span and the OTLP resource with this
+	// name. Defaults to "double-agent" if empty.
+	ServiceName string `hcl:"service_name,optional"`
+	// OTLPEndpoint, if set, exports spans to an OTLP/HTTP collector at
+	// this host:port (e.g. "localhost:4318"). Empty keeps spans
+	// in-process only.
+	OTLPEndpoint string `hcl:"otlp_endpoint,optional"`
+	// MetricsAddr, if set, serves Prometheus metrics at GET /metrics on
+	// this address, separate from every service's own listener and from
+	// AdminAddr. Empty disables the endpoint; metrics are still
+	// recorded, just unexposed.
+	MetricsAddr string `hcl:"metrics_addr,optional"`
+}
+
+// Grant restricts what a plugin type may read from its environment and
+// contact outbound, regardless of what its own manifest declares. It's
+// the admission gate's source of truth: if an installed external
+// plugin's manifest asks for more than its grant allows, the engine
+// refuses to start it rather than silently honoring the escalation,
+// e.g.:
+//
+//	grant "stripe-fake" {
+//	  env            = ["STRIPE_KEY"]
+//	  allow_outbound = ["api.example.com"]
+//	}
+type Grant struct {
+	Type          string   `hcl:"type,label"`
+	Env           []string `hcl:"env,optional"`
+	AllowOutbound []string `hcl:"allow_outbound,optional"`
+}
+
+// Signature declares how to verify a Service.Plugin bundle beyond its
+// content digest, e.g.:
+//
+//	signature "cosign" {
+//	  public_key = "/etc/doubleagent/keys/acme.pub"
+//	}
+type Signature struct {
+	Type      string `hcl:"type,label"` // "cosign" or "minisign"
+	PublicKey string `hcl:"public_key"`
 }
 
 // Service represents a single service block in the config.
 type Service struct {
-	Type    string            `hcl:"type,label"`
-	Name    string            `hcl:"name,label"`
-	Port    int               `hcl:"port"`
-	Version string            `hcl:"version,optional"`
-	Command []string          `hcl:"command,optional"`
-	Env     map[string]string `hcl:"env,optional"`
+	Type string `hcl:"type,label"`
+	Name string `hcl:"name,label"`
+	Port int    `hcl:"port,optional"`
+	// Listen overrides Port with a full listener spec understood by
+	// internal/listener: "tcp://[host]:port" (the default, equivalent to
+	// Port), "unix:///path/to.sock[?mode=0660&owner=alice]",
+	// "tls://[host]:port?cert=...&key=...[&client_ca=...]", or
+	// "systemd://name" to adopt a socket-activated fd. Empty falls back
+	// to Port.
+	Listen  string   `hcl:"listen,optional"`
+	Version string   `hcl:"version,optional"`
+	Command []string `hcl:"command,optional"`
+	// Plugin names a content-addressable plugin bundle to fetch and
+	// launch in place of Command: "oci://registry/repo@sha256:..." pulls
+	// an OCI artifact the way internal/pluginstore's alias-based Install
+	// does, while "https://host/path/bundle.tar.zst#sha256=..." fetches
+	// and verifies a plain tarball. Ignored if Command is set.
+	Plugin     string            `hcl:"plugin,optional"`
+	Signature  *Signature        `hcl:"signature,block"`
+	Env        map[string]string `hcl:"env,optional"`
+	WebhookURL []WebhookURL      `hcl:"webhook_url,block"`
+	Auth       *Auth             `hcl:"auth,block"`
+	Fault      *Fault            `hcl:"fault,block"`
+	// Seed is a path to a JSON snapshot loaded at startup via the
+	// plugin's Restore method, for services whose plugin implements
+	// sdk.Snapshotter. Ignored otherwise.
+	Seed string `hcl:"seed,optional"`
+	// Mode selects how the service answers requests: "fake" (the
+	// default) serves the plugin's own in-memory behavior; "record"
+	// proxies to Upstream and appends each interaction to
+	// fixtures/<name>.yaml; "replay" serves matching fixture
+	// interactions with no network access and 404s the rest.
+	Mode string `hcl:"mode,optional"`
+	// Upstream is the real API record mode proxies to. Required when
+	// Mode is "record", ignored otherwise.
+	Upstream string      `hcl:"upstream,optional"`
+	Scrub    []ScrubRule `hcl:"scrub,block"`
+}
+
+// ListenAddr returns the listen spec s.Listen names, falling back to
+// "tcp://:<Port>" for configs that only set the older Port field.
+func (s Service) ListenAddr() string {
+	if s.Listen != "" {
+		return s.Listen
+	}
+	return fmt.Sprintf("tcp://:%d", s.Port)
+}
+
+// ScrubRule redacts sensitive data from a record-mode cassette before
+// it's written to disk, e.g.:
+//
+//	scrub "header" {
+//	  fields = ["Authorization", "Set-Cookie"]
+//	}
+//	scrub "body" {
+//	  pattern = "\"token\":\"[^\"]+\""
+//	}
+type ScrubRule struct {
+	Type        string   `hcl:"type,label"` // "header" or "body"
+	Fields      []string `hcl:"fields,optional"`
+	Pattern     string   `hcl:"pattern,optional"`
+	Replacement string   `hcl:"replacement,optional"`
+}
+
+// Fault declares static fault injection for a service, applied to every
+// request from startup. Latency is an hcl string parsed with
+// time.ParseDuration (e.g. "200ms"); the finer-grained per-route rules
+// that faults.Config also supports are runtime-only, set via the
+// POST /_/faults admin endpoint rather than this block.
+type Fault struct {
+	Latency   string  `hcl:"latency,optional"`
+	ErrorRate float64 `hcl:"error_rate,optional"`
+	Status    int     `hcl:"status,optional"`
+	Body      string  `hcl:"body,optional"`
+	Truncate  int     `hcl:"truncate,optional"`
+	Drop      float64 `hcl:"drop,optional"`
+}
+
+// Auth declares the credential scheme a service requires. Type selects
+// which sdk/auth.AuthScheme the engine builds: "bearer", "basic",
+// "jira_session", or "github_pat".
+type Auth struct {
+	Type        string       `hcl:"type,label"`
+	Credentials []Credential `hcl:"credential,block"`
+}
+
+// Credential is one accepted identity for a service's Auth block, e.g.:
+//
+//	auth "bearer" {
+//	  credential "ci-bot" {
+//	    token  = "ghp_xxx"
+//	    scopes = ["repo", "read:org"]
+//	  }
+//	}
+type Credential struct {
+	Subject  string   `hcl:"subject,label"`
+	Token    string   `hcl:"token,optional"`
+	Password string   `hcl:"password,optional"`
+	Scopes   []string `hcl:"scopes,optional"`
+}
+
+// WebhookURL declares an outbound webhook target for a named event. A
+// service may declare one per event type it wants to emit, e.g.:
+//
+//	webhook_url "issues" {
+//	  url = "https://example.com/hooks/issues"
+//	}
+type WebhookURL struct {
+	Event string `hcl:"event,label"`
+	URL   string `hcl:"url"`
 }
 
 // Load parses an HCL config file and returns the Config.
@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/islo-labs/double-agent/pkg/sdk"
+	"github.com/islo-labs/double-agent/pkg/sdk/auth"
+)
+
+// redactedEnv masks the stand-in value displayed for a secret env var
+// in place of its real one, in admin views and anywhere else an
+// sdk.EnvVar marked Secret gets echoed back.
+const redactedEnv = "***"
+
+// adminInstanceView is what GET /plugins and GET /plugins/{name} return
+// for one instance: just enough to drive a test harness or orchestrator
+// without it needing a full sdk.Info round trip against the plugin's
+// own address.
+type adminInstanceView struct {
+	Type    string            `json:"type"`
+	Name    string            `json:"name"`
+	Listen  string            `json:"listen"`
+	State   string            `json:"state"`
+	Version string            `json:"version"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+func (e *Engine) instanceView(inst *Instance) adminInstanceView {
+	return adminInstanceView{
+		Type:    inst.Config.Type,
+		Name:    inst.Config.Name,
+		Listen:  inst.Config.ListenAddr(),
+		State:   string(inst.State()),
+		Version: inst.Plugin.Info().Version,
+		Env:     redactSecretEnv(inst.Config.Env, inst.Plugin.Manifest().RequiredEnv),
+	}
+}
+
+// redactSecretEnv returns a copy of env with every value whose name
+// matches a RequiredEnv entry marked Secret replaced by redactedEnv, so
+// GET /plugins never echoes back an API token or password a manifest
+// flagged as sensitive.
+func redactSecretEnv(env map[string]string, required []sdk.EnvVar) map[string]string {
+	if len(env) == 0 {
+		return env
+	}
+	secret := make(map[string]bool, len(required))
+	for _, v := range required {
+		if v.Secret {
+			secret[v.Name] = true
+		}
+	}
+	out := make(map[string]string, len(env))
+	for k, v := range env {
+		if secret[k] {
+			v = redactedEnv
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func (e *Engine) findInstance(name string) (*Instance, bool) {
+	for _, inst := range e.instances {
+		if inst.Config.Name == name {
+			return inst, true
+		}
+	}
+	return nil, false
+}
+
+// AdminHandler returns the control-plane HTTP handler served on
+// config.Config.AdminAddr, separate from each plugin's own address, so
+// a test harness or orchestrator always has one fixed place to drive
+// doubleagent from regardless of what ports the services themselves are
+// configured with: GET /plugins, GET /plugins/{name}, POST
+// /plugins/{name}/{enable,disable,restart,reset}, and POST
+// /service/stop. shutdown is called once the stop response has been
+// written, to cancel the process's run loop gracefully. If token is
+// non-empty, every route requires "Authorization: Bearer <token>";
+// config.Config.AdminToken is where this comes from.
+func (e *Engine) AdminHandler(shutdown context.CancelFunc, token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /plugins", func(w http.ResponseWriter, r *http.Request) {
+		views := make([]adminInstanceView, 0, len(e.instances))
+		for _, inst := range e.instances {
+			views = append(views, e.instanceView(inst))
+		}
+		writeJSON(w, http.StatusOK, views)
+	})
+
+	mux.HandleFunc("GET /plugins/{name}", func(w http.ResponseWriter, r *http.Request) {
+		inst, ok := e.findInstance(r.PathValue("name"))
+		if !ok {
+			http.Error(w, `{"error":"no such plugin"}`, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, e.instanceView(inst))
+	})
+
+	mux.HandleFunc("POST /plugins/{name}/disable", func(w http.ResponseWriter, r *http.Request) {
+		e.withInstance(w, r, func(inst *Instance) error { return inst.Disable(r.Context()) })
+	})
+	mux.HandleFunc("POST /plugins/{name}/enable", func(w http.ResponseWriter, r *http.Request) {
+		e.withInstance(w, r, func(inst *Instance) error { return inst.Enable() })
+	})
+	mux.HandleFunc("POST /plugins/{name}/restart", func(w http.ResponseWriter, r *http.Request) {
+		e.withInstance(w, r, func(inst *Instance) error { return inst.Restart(r.Context()) })
+	})
+	mux.HandleFunc("POST /plugins/{name}/reset", func(w http.ResponseWriter, r *http.Request) {
+		e.withInstance(w, r, func(inst *Instance) error { return inst.Plugin.Reset() })
+	})
+
+	mux.HandleFunc("POST /service/stop", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "stopping"})
+		if shutdown != nil {
+			shutdown()
+		}
+	})
+
+	if token == "" {
+		return mux
+	}
+	store := auth.NewCredentialStore([]auth.Credential{{Subject: "admin", Secret: token}})
+	return auth.Middleware(auth.BearerToken{Credentials: store}, mux)
+}
+
+// withInstance looks up the {name} path value, runs op against its
+// Instance, and writes the usual {"status":"ok"} envelope or a JSON
+// error, so each lifecycle verb above doesn't repeat the lookup and
+// response boilerplate.
+func (e *Engine) withInstance(w http.ResponseWriter, r *http.Request, op func(*Instance) error) {
+	inst, ok := e.findInstance(r.PathValue("name"))
+	if !ok {
+		http.Error(w, `{"error":"no such plugin"}`, http.StatusNotFound)
+		return
+	}
+	if err := op(inst); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
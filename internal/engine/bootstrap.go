@@ -0,0 +1,298 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/islo-labs/double-agent/internal/config"
+)
+
+// upgradeReadyTimeout bounds how long upgrade waits for a re-exec'd
+// child to signal readiness. Without it, a child that wedges after
+// inheriting the listener fds but before calling signalReady (e.g. it
+// deadlocks opening one) would block this read forever, and with it
+// the signal-handling loop in Run - so a stuck child fails the upgrade
+// instead of making the parent unrecoverable.
+const upgradeReadyTimeout = 30 * time.Second
+
+// readyFDEnv names the env var a re-exec'd child reads to learn which
+// fd is the pipe it should write a single byte to once it has opened
+// every listener and is ready to take over, so its parent (still
+// serving the previous generation) knows it's safe to shut itself
+// down.
+const readyFDEnv = "DOUBLE_READY_FD"
+
+// Bootstrap runs an Engine built from ConfigPath and manages it across
+// graceful reconfiguration and zero-downtime binary upgrades, in the
+// style of Gitaly's bootstrap/starter packages:
+//
+//   - SIGHUP re-reads ConfigPath and swaps in a new Engine built from
+//     it. Each server drains its in-flight requests via Shutdown
+//     before the new Engine starts serving, so no request is dropped
+//     mid-flight; the listening socket itself is briefly unbound while
+//     the old one closes and the new one binds.
+//   - SIGUSR2 re-execs the running binary, passing every open listener
+//     fd to the child as inherited files (so the child's bind is instant,
+//     with no rebind gap) and waiting for the child to signal
+//     readiness on a pipe before shutting the parent down.
+//
+// Bootstrap is the entry point `double run` uses; Engine.New/Run remain
+// usable directly by anything that wants a plain, non-upgrading server.
+type Bootstrap struct {
+	ConfigPath string
+
+	mu         sync.Mutex
+	eng        *Engine
+	cancel     context.CancelFunc
+	done       chan error
+	adminSrv   *http.Server
+	metricsSrv *http.Server
+
+	// shutdown cancels Run's ctx, wired to the admin control plane's
+	// POST /service/stop so it can trigger a full graceful shutdown
+	// rather than just this generation's serveCtx.
+	shutdown context.CancelFunc
+}
+
+// Run loads ConfigPath, starts serving, and blocks until ctx is
+// cancelled, reloading on SIGHUP and upgrading on SIGUSR2 along the
+// way.
+func (b *Bootstrap) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	b.shutdown = cancel
+
+	cfg, err := config.Load(b.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if err := b.start(cfg); err != nil {
+		return err
+	}
+	if fd := os.Getenv(readyFDEnv); fd != "" {
+		signalReady(fd)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP, syscall.SIGUSR2)
+	defer signal.Stop(sig)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return b.stop()
+		case s := <-sig:
+			switch s {
+			case syscall.SIGHUP:
+				if err := b.reload(); err != nil {
+					log.Printf("reload failed, keeping current config: %v", err)
+				}
+			case syscall.SIGUSR2:
+				switch done, err := b.upgrade(); {
+				case err != nil:
+					log.Printf("upgrade failed, continuing on current process: %v", err)
+				case done:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// start builds an Engine from cfg, opens its listeners, and serves it
+// (plus the admin control plane, if cfg.AdminAddr is set) in the
+// background, replacing whatever Engine Bootstrap was previously
+// running.
+func (b *Bootstrap) start(cfg *config.Config) error {
+	eng, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	if err := eng.Listen(); err != nil {
+		return err
+	}
+
+	log.Printf("DoubleAgent starting with %d service(s)", len(cfg.Services))
+	serveCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- eng.Serve(serveCtx) }()
+
+	var adminSrv *http.Server
+	if cfg.AdminAddr != "" {
+		adminSrv = &http.Server{Addr: cfg.AdminAddr, Handler: eng.AdminHandler(b.shutdown, cfg.AdminToken)}
+		go func() {
+			log.Printf("admin control plane listening on %s", cfg.AdminAddr)
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("admin listener error: %v", err)
+			}
+		}()
+	}
+
+	var metricsSrv *http.Server
+	if addr := eng.MetricsAddr(); addr != "" {
+		metricsSrv = &http.Server{Addr: addr, Handler: eng.MetricsHandler()}
+		go func() {
+			log.Printf("metrics listening on %s", addr)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("metrics listener error: %v", err)
+			}
+		}()
+	}
+
+	b.mu.Lock()
+	b.eng, b.cancel, b.done, b.adminSrv, b.metricsSrv = eng, cancel, done, adminSrv, metricsSrv
+	b.mu.Unlock()
+	return nil
+}
+
+// stop cancels the currently running Engine's Serve, waits for it to
+// finish draining, and shuts down the admin and metrics listeners
+// alongside it.
+func (b *Bootstrap) stop() error {
+	b.mu.Lock()
+	cancel, done, adminSrv, metricsSrv := b.cancel, b.done, b.adminSrv, b.metricsSrv
+	b.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	err := <-done
+	if adminSrv != nil {
+		adminSrv.Shutdown(context.Background())
+	}
+	if metricsSrv != nil {
+		metricsSrv.Shutdown(context.Background())
+	}
+	return err
+}
+
+// reload re-reads ConfigPath and swaps in a new Engine built from it,
+// stopping the previous one first so its servers drain in-flight
+// requests via Shutdown rather than cutting them off.
+func (b *Bootstrap) reload() error {
+	cfg, err := config.Load(b.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	log.Printf("reloading config from %s", b.ConfigPath)
+	if err := b.stop(); err != nil {
+		log.Printf("error stopping previous engine: %v", err)
+	}
+	return b.start(cfg)
+}
+
+// upgrade re-execs the running binary, handing it the current Engine's
+// open listener fds, and waits for the child to report readiness
+// before stopping. The bool return reports whether the child took over
+// successfully; when true the caller should exit without serving
+// further, leaving the child as the sole process bound to the
+// listeners.
+func (b *Bootstrap) upgrade() (bool, error) {
+	b.mu.Lock()
+	eng := b.eng
+	b.mu.Unlock()
+
+	listeners := eng.listenerSnapshot()
+	addrs := make([]string, 0, len(listeners))
+	for addr := range listeners {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs) // deterministic fd ordering between parent and child
+
+	files := make([]*os.File, 0, len(addrs))
+	for _, addr := range addrs {
+		f, err := listenerFile(listeners[addr])
+		if err != nil {
+			return false, fmt.Errorf("collecting listener fd for %s: %w", addr, err)
+		}
+		files = append(files, f)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return false, fmt.Errorf("creating readiness pipe: %w", err)
+	}
+	defer r.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return false, fmt.Errorf("resolving executable: %w", err)
+	}
+
+	// fd numbering: 0-2 are the inherited stdio below, so the N
+	// listener fds land at 3..3+N-1 and the readiness pipe right after.
+	childFiles := append([]*os.File{os.Stdin, os.Stdout, os.Stderr}, files...)
+	childFiles = append(childFiles, w)
+	readyFD := 3 + len(files)
+
+	env := append(os.Environ(),
+		listenFDsEnv+"="+fdMapping(addrs),
+		fmt.Sprintf("%s=%d", readyFDEnv, readyFD),
+	)
+
+	proc, err := os.StartProcess(exe, os.Args, &os.ProcAttr{Env: env, Files: childFiles})
+	if err != nil {
+		w.Close()
+		return false, fmt.Errorf("starting upgraded process: %w", err)
+	}
+	w.Close() // only the child's copy matters from here
+
+	log.Printf("spawned upgraded process pid=%d, waiting for readiness", proc.Pid)
+	if err := waitForReady(r, upgradeReadyTimeout); err != nil {
+		return false, err
+	}
+
+	log.Println("upgraded process ready, shutting down")
+	return true, b.stop()
+}
+
+// waitForReady blocks on r until the re-exec'd child writes its
+// readiness byte or timeout elapses, bounding what would otherwise be
+// an indefinite read: a child that wedges after inheriting the
+// listener fds but before calling signalReady must fail the upgrade
+// rather than hang Bootstrap.upgrade (and with it, Run's whole
+// signal-handling loop) forever.
+func waitForReady(r *os.File, timeout time.Duration) error {
+	if err := r.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return fmt.Errorf("setting readiness deadline: %w", err)
+	}
+	ready := make([]byte, 1)
+	if _, err := r.Read(ready); err != nil {
+		return fmt.Errorf("upgraded process did not signal readiness within %s: %w", timeout, err)
+	}
+	return nil
+}
+
+// fdMapping formats addrs into the listenFDsEnv value a child process
+// parses, assigning fds in order starting at 3 (upgrade passes the
+// listener files right after stdin/stdout/stderr).
+func fdMapping(addrs []string) string {
+	parts := make([]string, len(addrs))
+	for i, addr := range addrs {
+		parts[i] = addr + "=" + strconv.Itoa(3+i)
+	}
+	return strings.Join(parts, ",")
+}
+
+// signalReady writes a single byte to the pipe fd named by fdStr,
+// telling a graceful-upgrade parent this process has finished opening
+// its listeners and it's safe to stop.
+func signalReady(fdStr string) {
+	fd, err := strconv.Atoi(fdStr)
+	if err != nil {
+		return
+	}
+	f := os.NewFile(uintptr(fd), "ready")
+	defer f.Close()
+	f.Write([]byte{1})
+}
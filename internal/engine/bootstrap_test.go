@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWaitForReady_TimesOutOnStuckChild is the regression test for a
+// review comment: upgrade used to block on r.Read(ready) with no
+// deadline, so a re-exec'd child that wedged before calling
+// signalReady would hang Bootstrap.upgrade (and Run's signal-handling
+// loop with it) forever instead of failing the upgrade.
+func TestWaitForReady_TimesOutOnStuckChild(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	err = waitForReady(r, 50*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not signal readiness")
+}
+
+// TestWaitForReady_SucceedsOnSignal confirms the happy path still
+// works once a deadline is in play.
+func TestWaitForReady_SucceedsOnSignal(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+
+	go func() {
+		w.Write([]byte{1})
+		w.Close()
+	}()
+
+	assert.NoError(t, waitForReady(r, time.Second))
+}
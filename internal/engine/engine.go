@@ -3,15 +3,29 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/islo-labs/double-agent/internal/builtin"
+	"github.com/islo-labs/double-agent/internal/cassette"
 	"github.com/islo-labs/double-agent/internal/config"
+	"github.com/islo-labs/double-agent/internal/observability"
+	"github.com/islo-labs/double-agent/internal/pluginstore"
+	"github.com/islo-labs/double-agent/internal/supervisor"
+	"github.com/islo-labs/double-agent/internal/webhook"
 	"github.com/islo-labs/double-agent/pkg/sdk"
+	"github.com/islo-labs/double-agent/pkg/sdk/auth"
+	"github.com/islo-labs/double-agent/pkg/sdk/faults"
 )
 
 // Instance is a running plugin instance.
@@ -19,26 +33,199 @@ type Instance struct {
 	Config   config.Service
 	Plugin   sdk.Plugin
 	Server   *http.Server
-	external *sdk.ExternalPlugin // non-nil for external plugins
+	external *supervisor.Supervisor // non-nil for external plugins
+
+	mu       sync.Mutex
+	listener net.Listener // non-nil while the instance is serving traffic
+}
+
+// State reports where the instance sits in its restart lifecycle. A
+// built-in plugin has no subprocess to crash or restart, so it's always
+// StateRunning; an external plugin defers to its Supervisor.
+func (i *Instance) State() supervisor.State {
+	if i.external == nil {
+		return supervisor.StateRunning
+	}
+	return i.external.State()
+}
+
+// listen opens i's listener via store, adopting one inherited across a
+// graceful upgrade if store has one for i's address.
+func (i *Instance) listen(store *listenerStore) error {
+	ln, err := store.Listen(i.Server.Addr)
+	if err != nil {
+		return err
+	}
+	i.mu.Lock()
+	i.listener = ln
+	i.mu.Unlock()
+	return nil
+}
+
+// serve runs i's HTTP server on its currently open listener until the
+// listener is closed (by Disable, or the engine shutting down),
+// reporting any error other than the expected "server closed" on errCh.
+func (i *Instance) serve(errCh chan<- error) {
+	i.mu.Lock()
+	ln := i.listener
+	i.mu.Unlock()
+	if err := i.Server.Serve(ln); err != nil && err != http.ErrServerClosed {
+		errCh <- err
+	}
+}
+
+// Disable stops i from serving HTTP traffic, draining in-flight
+// requests via Shutdown, while leaving the underlying plugin configured
+// so Enable can resume without reconfiguring it. A no-op if already
+// disabled.
+func (i *Instance) Disable(ctx context.Context) error {
+	i.mu.Lock()
+	srv, ln := i.Server, i.listener
+	i.mu.Unlock()
+	if ln == nil {
+		return nil
+	}
+	err := srv.Shutdown(ctx)
+	i.mu.Lock()
+	i.listener = nil
+	i.mu.Unlock()
+	return err
+}
+
+// Enable opens a fresh listener for i's address and resumes serving. It
+// rebuilds the *http.Server, since net/http forbids reusing one after
+// Shutdown. A no-op if already enabled.
+func (i *Instance) Enable() error {
+	i.mu.Lock()
+	if i.listener != nil {
+		i.mu.Unlock()
+		return nil
+	}
+	addr, handler := i.Server.Addr, i.Server.Handler
+	i.mu.Unlock()
+
+	ln, err := newListenerStore().Listen(addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", addr, err)
+	}
+	srv := &http.Server{Addr: addr, Handler: handler}
+
+	i.mu.Lock()
+	i.Server = srv
+	i.listener = ln
+	i.mu.Unlock()
+
+	errCh := make(chan error, 1)
+	go i.serve(errCh)
+	go func() {
+		if err := <-errCh; err != nil {
+			log.Printf("instance %s/%s: serve error: %v", i.Config.Type, i.Config.Name, err)
+		}
+	}()
+	return nil
+}
+
+// Restart bounces i's listener, the simplest way to give it a clean
+// HTTP server without discarding its configured plugin state.
+func (i *Instance) Restart(ctx context.Context) error {
+	if err := i.Disable(ctx); err != nil {
+		return err
+	}
+	return i.Enable()
 }
 
 // Engine manages plugin instances.
 type Engine struct {
-	instances []*Instance
+	instances     []*Instance
+	observability *observability.Provider
+	metricsAddr   string
+}
+
+// MetricsHandler serves the Prometheus metrics this Engine's services
+// recorded, for Bootstrap to mount at config.Observability.MetricsAddr.
+func (e *Engine) MetricsHandler() http.Handler {
+	return e.observability.MetricsHandler()
 }
 
+// MetricsAddr is the address config.Observability.MetricsAddr named, or
+// "" if metrics aren't exposed over HTTP.
+func (e *Engine) MetricsAddr() string {
+	return e.metricsAddr
+}
+
+// listenerSnapshot returns each currently-serving instance's listener,
+// keyed by address, for Bootstrap to hand off to a child process across
+// a graceful upgrade.
+func (e *Engine) listenerSnapshot() map[string]net.Listener {
+	m := make(map[string]net.Listener, len(e.instances))
+	for _, inst := range e.instances {
+		inst.mu.Lock()
+		ln := inst.listener
+		inst.mu.Unlock()
+		if ln != nil {
+			m[inst.Server.Addr] = ln
+		}
+	}
+	return m
+}
+
+// webhookEnvPrefix is prepended to the event name of each configured
+// webhook_url block and folded into the plugin's env, so plugins learn
+// their webhook targets the same way they learn everything else: via the
+// flat env map passed to Configure.
+const webhookEnvPrefix = "WEBHOOK_URL_"
+
 // New creates an Engine from the given config.
 func New(cfg *config.Config) (*Engine, error) {
-	e := &Engine{}
+	provider, err := observability.New(cfg.Observability)
+	if err != nil {
+		return nil, fmt.Errorf("configuring observability: %w", err)
+	}
+	e := &Engine{observability: provider}
+	if cfg.Observability != nil {
+		e.metricsAddr = cfg.Observability.MetricsAddr
+	}
+	dispatcher := webhook.New()
+	grants := make(map[string]*config.Grant, len(cfg.Grants))
+	for i := range cfg.Grants {
+		grants[cfg.Grants[i].Type] = &cfg.Grants[i]
+	}
 	for _, svc := range cfg.Services {
 		var (
 			p   sdk.Plugin
-			ext *sdk.ExternalPlugin
+			ext *supervisor.Supervisor
 		)
-		if len(svc.Command) > 0 {
-			// External plugin: spawn subprocess.
+		command := svc.Command
+		if len(command) == 0 && svc.Plugin != "" {
+			// Not a literal command: fetch the content-addressable bundle
+			// named by Plugin and launch its entrypoint, the same way an
+			// installed alias's resolved command is launched below.
+			resolved, err := resolveBundlePlugin(svc)
+			if err != nil {
+				return nil, fmt.Errorf("resolving plugin bundle for %s/%s: %w", svc.Type, svc.Name, err)
+			}
+			command = resolved
+		}
+		if len(command) == 0 {
+			// Not a literal command: fall back to an installed
+			// third-party plugin aliased to the service's type, if any,
+			// before giving up as unknown.
+			resolved, ok, err := resolveInstalledPlugin(cfg, svc.Type)
+			if err != nil {
+				return nil, fmt.Errorf("resolving plugin %s/%s: %w", svc.Type, svc.Name, err)
+			}
+			if ok {
+				command = resolved
+			}
+		}
+		if len(command) > 0 {
+			// External plugin: launch and supervise the subprocess.
 			var err error
-			ext, err = sdk.StartExternalPlugin(svc.Command)
+			ext, err = supervisor.Start(supervisor.Config{
+				Name:    svc.Name,
+				Command: command,
+				Dir:     cfg.PluginDir,
+			})
 			if err != nil {
 				return nil, fmt.Errorf("starting external plugin %s/%s: %w", svc.Type, svc.Name, err)
 			}
@@ -51,26 +238,144 @@ func New(cfg *config.Config) (*Engine, error) {
 			}
 			p = newFn()
 		}
-		if err := p.Configure(svc.Env); err != nil {
+		env, err := admit(p.Manifest(), grants[svc.Type], envWithWebhooks(svc))
+		if err != nil {
+			return nil, fmt.Errorf("admission for %s/%s: %w", svc.Type, svc.Name, err)
+		}
+		if err := p.Configure(env); err != nil {
 			return nil, fmt.Errorf("configuring %s/%s: %w", svc.Type, svc.Name, err)
 		}
+		if notifier, ok := p.(sdk.Notifier); ok {
+			notifier.SetNotifyFunc(dispatcher.Handle)
+		}
+		if svc.Seed != "" {
+			if err := seedPlugin(p, svc.Seed); err != nil {
+				return nil, fmt.Errorf("seeding %s/%s: %w", svc.Type, svc.Name, err)
+			}
+		}
+
+		var handler http.Handler = p
+		switch svc.Mode {
+		case "record":
+			handler, err = buildCassetteRecorder(svc, provider)
+		case "replay":
+			handler, err = buildCassetteReplayer(svc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("configuring mode %q for %s/%s: %w", svc.Mode, svc.Type, svc.Name, err)
+		}
+
+		var credStore *auth.CredentialStore
+		if svc.Auth != nil {
+			scheme, store, err := buildAuthScheme(svc.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("configuring auth for %s/%s: %w", svc.Type, svc.Name, err)
+			}
+			credStore = store
+			handler = auth.Middleware(scheme, handler)
+		}
+
+		// faultMW wraps the outermost handler so an injected drop or
+		// latency spike applies before auth even runs, the way a flaky
+		// network or overloaded edge would behave for real.
+		faultMW := faults.Wrap(handler)
+		if svc.Fault != nil {
+			rule, err := buildFaultRule(svc.Fault)
+			if err != nil {
+				return nil, fmt.Errorf("configuring fault for %s/%s: %w", svc.Type, svc.Name, err)
+			}
+			faultMW.SetConfig(&faults.Config{Rule: rule})
+		}
+		handler = faultMW
+
 		mux := http.NewServeMux()
 		mux.HandleFunc("POST /_/reset", func(w http.ResponseWriter, r *http.Request) {
 			if err := p.Reset(); err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
+			if credStore != nil && r.URL.Query().Get("keep") != "credentials" {
+				credStore.Reset()
+			}
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, `{"status":"ok"}`)
+		})
+		if credStore != nil {
+			mux.HandleFunc("POST /_/credentials", func(w http.ResponseWriter, r *http.Request) {
+				var cred auth.Credential
+				if err := json.NewDecoder(r.Body).Decode(&cred); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				credStore.Add(cred)
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintln(w, `{"status":"ok"}`)
+			})
+			mountOAuth(mux, svc.Auth.Type, credStore)
+		}
+		mux.HandleFunc("POST /_/faults", func(w http.ResponseWriter, r *http.Request) {
+			var cfg faults.Config
+			if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			faultMW.SetConfig(&cfg)
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, `{"status":"ok"}`)
+		})
+		mux.HandleFunc("DELETE /_/faults", func(w http.ResponseWriter, r *http.Request) {
+			faultMW.SetConfig(nil)
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, `{"status":"ok"}`)
+		})
+		mux.HandleFunc("GET /_/snapshot", func(w http.ResponseWriter, r *http.Request) {
+			snapshotter, ok := p.(sdk.Snapshotter)
+			if !ok {
+				http.Error(w, `{"error":"plugin does not support snapshotting"}`, http.StatusNotImplemented)
+				return
+			}
+			data, err := snapshotter.Snapshot()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(data)
+		})
+		mux.HandleFunc("POST /_/restore", func(w http.ResponseWriter, r *http.Request) {
+			snapshotter, ok := p.(sdk.Snapshotter)
+			if !ok {
+				http.Error(w, `{"error":"plugin does not support snapshotting"}`, http.StatusNotImplemented)
+				return
+			}
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := snapshotter.Restore(data); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
 			w.WriteHeader(http.StatusOK)
 			fmt.Fprintln(w, `{"status":"ok"}`)
 		})
-		mux.Handle("/", p)
+		mux.HandleFunc("GET /_/health", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if ext == nil {
+				w.Write([]byte(`{"state":"` + string(supervisor.StateRunning) + `"}`))
+				return
+			}
+			w.Write(ext.Health())
+		})
+		mux.Handle("/", handler)
 		inst := &Instance{
 			Config:   svc,
 			Plugin:   p,
 			external: ext,
 			Server: &http.Server{
-				Addr:    fmt.Sprintf(":%d", svc.Port),
-				Handler: mux,
+				Addr:    svc.ListenAddr(),
+				Handler: provider.Middleware(svc.Type, svc.Name)(mux),
 			},
 		}
 		e.instances = append(e.instances, inst)
@@ -78,28 +383,36 @@ func New(cfg *config.Config) (*Engine, error) {
 	return e, nil
 }
 
-// Run starts all HTTP servers and blocks until the context is cancelled.
-func (e *Engine) Run(ctx context.Context) error {
+// Listen opens a net.Listener for each configured service, adopting any
+// handed down from a parent process via DOUBLE_LISTEN_FDS (see
+// Bootstrap) and dialing a fresh one otherwise. Call it once before
+// Serve.
+func (e *Engine) Listen() error {
+	store := newListenerStore()
+	for _, inst := range e.instances {
+		if err := inst.listen(store); err != nil {
+			return fmt.Errorf("listen %s: %w", inst.Server.Addr, err)
+		}
+	}
+	return nil
+}
+
+// Serve starts all HTTP servers on the listeners opened by Listen and
+// blocks until ctx is cancelled, then gracefully shuts every server
+// down (draining in-flight requests) before returning.
+func (e *Engine) Serve(ctx context.Context) error {
 	var wg sync.WaitGroup
 	errCh := make(chan error, len(e.instances))
 
 	for _, inst := range e.instances {
 		info := inst.Plugin.Info()
-		addr := inst.Server.Addr
-		log.Printf("starting %s/%s (%s) on %s", inst.Config.Type, inst.Config.Name, info.Version, addr)
-
-		ln, err := net.Listen("tcp", addr)
-		if err != nil {
-			return fmt.Errorf("listen %s: %w", addr, err)
-		}
+		log.Printf("starting %s/%s (%s) on %s", inst.Config.Type, inst.Config.Name, info.Version, inst.Server.Addr)
 
 		wg.Add(1)
-		go func(srv *http.Server, ln net.Listener) {
+		go func(inst *Instance) {
 			defer wg.Done()
-			if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
-				errCh <- err
-			}
-		}(inst.Server, ln)
+			inst.serve(errCh)
+		}(inst)
 	}
 
 	// Wait for context cancellation.
@@ -108,7 +421,7 @@ func (e *Engine) Run(ctx context.Context) error {
 
 	// Shutdown all servers, then stop external plugins.
 	for _, inst := range e.instances {
-		if err := inst.Server.Shutdown(context.Background()); err != nil {
+		if err := inst.Disable(context.Background()); err != nil {
 			log.Printf("error shutting down %s/%s: %v", inst.Config.Type, inst.Config.Name, err)
 		}
 		if inst.external != nil {
@@ -119,6 +432,10 @@ func (e *Engine) Run(ctx context.Context) error {
 	}
 	wg.Wait()
 
+	if err := e.observability.Shutdown(context.Background()); err != nil {
+		log.Printf("error shutting down observability provider: %v", err)
+	}
+
 	select {
 	case err := <-errCh:
 		return err
@@ -126,3 +443,273 @@ func (e *Engine) Run(ctx context.Context) error {
 		return nil
 	}
 }
+
+// Run opens a fresh listener for every service and serves until ctx is
+// cancelled. It's the simple, non-upgrading entry point; Bootstrap
+// composes Listen and Serve directly to add config reload and
+// zero-downtime re-exec on top.
+func (e *Engine) Run(ctx context.Context) error {
+	if err := e.Listen(); err != nil {
+		return err
+	}
+	return e.Serve(ctx)
+}
+
+// envWithWebhooks merges svc's declared webhook targets into its env map
+// under WEBHOOK_URL_<event> keys, leaving svc.Env untouched if there are
+// none to add.
+func envWithWebhooks(svc config.Service) map[string]string {
+	if len(svc.WebhookURL) == 0 {
+		return svc.Env
+	}
+	env := make(map[string]string, len(svc.Env)+len(svc.WebhookURL))
+	for k, v := range svc.Env {
+		env[k] = v
+	}
+	for _, w := range svc.WebhookURL {
+		env[webhookEnvPrefix+w.Event] = w.URL
+	}
+	return env
+}
+
+// cassettePath is where a service's fixtures are recorded to and
+// replayed from, relative to the working directory double is run from.
+func cassettePath(svc config.Service) string {
+	return filepath.Join("fixtures", svc.Name+".yaml")
+}
+
+// buildScrubRules translates a service's HCL scrub blocks into
+// cassette.ScrubRule, compiling body patterns once up front so a
+// malformed regexp fails at startup rather than on first recorded
+// request.
+func buildScrubRules(rules []config.ScrubRule) ([]cassette.ScrubRule, error) {
+	out := make([]cassette.ScrubRule, 0, len(rules))
+	for _, r := range rules {
+		switch r.Type {
+		case "header":
+			out = append(out, cassette.ScrubRule{Headers: r.Fields, Replacement: r.Replacement})
+		case "body":
+			pattern, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("compiling scrub pattern %q: %w", r.Pattern, err)
+			}
+			out = append(out, cassette.ScrubRule{BodyPattern: pattern, Replacement: r.Replacement})
+		default:
+			return nil, fmt.Errorf("unknown scrub type: %q", r.Type)
+		}
+	}
+	return out, nil
+}
+
+// buildCassetteRecorder builds the handler backing mode = "record": it
+// proxies to svc.Upstream and appends every interaction to the
+// service's cassette, propagating the inbound request's trace context
+// to the upstream call via provider.
+func buildCassetteRecorder(svc config.Service, provider *observability.Provider) (http.Handler, error) {
+	if svc.Upstream == "" {
+		return nil, fmt.Errorf(`mode = "record" requires upstream`)
+	}
+	rules, err := buildScrubRules(svc.Scrub)
+	if err != nil {
+		return nil, err
+	}
+	cass, err := cassette.Load(cassettePath(svc))
+	if err != nil {
+		return nil, err
+	}
+	return &cassette.Recorder{Upstream: svc.Upstream, Cassette: cass, Scrub: rules, Propagator: provider.Propagate}, nil
+}
+
+// buildCassetteReplayer builds the handler backing mode = "replay": it
+// serves matching interactions from the service's cassette and 404s
+// anything it hasn't recorded.
+func buildCassetteReplayer(svc config.Service) (http.Handler, error) {
+	cass, err := cassette.Load(cassettePath(svc))
+	if err != nil {
+		return nil, err
+	}
+	return &cassette.Replayer{Cassette: cass}, nil
+}
+
+// buildAuthScheme constructs the sdk/auth.AuthScheme declared by a
+// service's auth block, along with the CredentialStore backing it, so
+// the engine can also wire POST /_/credentials and an OAuth mint flow
+// against the same set of credentials the scheme authenticates against.
+func buildAuthScheme(a *config.Auth) (auth.AuthScheme, *auth.CredentialStore, error) {
+	creds := make([]auth.Credential, 0, len(a.Credentials))
+	for _, c := range a.Credentials {
+		secret, kind := c.Token, auth.KindToken
+		if secret == "" {
+			secret, kind = c.Password, auth.KindLoginPassword
+		}
+		creds = append(creds, auth.Credential{Subject: c.Subject, Secret: secret, Scopes: c.Scopes, Kind: kind})
+	}
+	store := auth.NewCredentialStore(creds)
+
+	var scheme auth.AuthScheme
+	switch a.Type {
+	case "bearer":
+		scheme = auth.BearerToken{Credentials: store}
+	case "basic":
+		scheme = auth.BasicAuth{Credentials: store}
+	case "jira_session":
+		scheme = auth.JiraSessionCookie{Credentials: store}
+	case "github_pat":
+		scheme = auth.GitHubPAT{Credentials: store}
+	default:
+		return nil, nil, fmt.Errorf("unknown auth type: %q", a.Type)
+	}
+	return scheme, store, nil
+}
+
+// mountOAuth wires the vendor-shaped OAuth code-exchange endpoints for
+// auth types that have one, minting tokens straight into store so a
+// client that completes the flow can use the result immediately.
+func mountOAuth(mux *http.ServeMux, authType string, store *auth.CredentialStore) {
+	switch authType {
+	case "github_pat":
+		flow := &auth.OAuthCodeFlow{Store: store, TokenPrefix: "gho_"}
+		mux.HandleFunc("GET /login/oauth/authorize", flow.Authorize)
+		mux.HandleFunc("POST /login/oauth/access_token", flow.AccessToken)
+	case "jira_session":
+		// Real Jira Cloud's OAuth2 3LO round-trips through a separate
+		// accounts host this fake doesn't model; this is a same-host
+		// stand-in so a test can still mint a usable session.
+		flow := &auth.OAuthCodeFlow{Store: store}
+		mux.HandleFunc("GET /rest/oauth2/latest/authorize", flow.Authorize)
+		mux.HandleFunc("POST /rest/oauth2/latest/token", flow.AccessToken)
+	}
+}
+
+// resolveInstalledPlugin looks up alias in the pluginstore index rooted
+// at cfg.PluginDir and, if found and enabled, returns the command the
+// supervisor should launch for it. The builtin.Registry stays the
+// fallback for any type that isn't an installed alias, so configs with
+// no installed plugins behave exactly as before.
+func resolveInstalledPlugin(cfg *config.Config, alias string) ([]string, bool, error) {
+	if cfg.PluginDir == "" {
+		return nil, false, nil
+	}
+	store, err := pluginstore.New(cfg.PluginDir)
+	if err != nil {
+		return nil, false, err
+	}
+	return store.Resolve(alias)
+}
+
+// resolveBundlePlugin fetches svc.Plugin's content-addressable bundle
+// and returns the command to launch for it, failing fast if the
+// bundle's manifest declares a Type that doesn't match svc.Type. admit
+// can't catch that mismatch itself: it only sees whatever the live
+// subprocess reports once spawned, not the bundle's own declared Type.
+func resolveBundlePlugin(svc config.Service) ([]string, error) {
+	var sig *pluginstore.Signature
+	if svc.Signature != nil {
+		sig = &pluginstore.Signature{Type: svc.Signature.Type, PublicKey: svc.Signature.PublicKey}
+	}
+	command, manifest, err := pluginstore.FetchBundle(svc.Plugin, sig)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Type != "" && manifest.Type != svc.Type {
+		return nil, fmt.Errorf("bundle declares type %q, service is type %q", manifest.Type, svc.Type)
+	}
+	return command, nil
+}
+
+// admit runs the plugin/host admission gate before Configure is ever
+// called: it rejects an API version mismatch, narrows env to grant's
+// allowlist and checks the manifest's declared outbound hosts against it
+// (if a grant is configured for the service's type), and finally
+// verifies every env var the manifest requires is actually present.
+// It returns the env Configure should receive.
+func admit(manifest sdk.PluginManifest, grant *config.Grant, env map[string]string) (map[string]string, error) {
+	if manifest.APIVersion != "" && manifest.APIVersion != sdk.APIVersion {
+		return nil, fmt.Errorf("plugin requires API version %q, host supports %q", manifest.APIVersion, sdk.APIVersion)
+	}
+
+	if grant != nil {
+		env = filterEnv(env, grant.Env)
+		if extra := disallowedHosts(manifest.AllowOutbound, grant.AllowOutbound); len(extra) > 0 {
+			return nil, fmt.Errorf("manifest declares outbound hosts not granted: %s", strings.Join(extra, ", "))
+		}
+	}
+
+	var missing []string
+	for _, req := range manifest.RequiredEnv {
+		if _, ok := env[req.Name]; !ok {
+			missing = append(missing, req.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required env var(s): %s", strings.Join(missing, ", "))
+	}
+
+	return env, nil
+}
+
+// filterEnv returns the subset of env whose keys appear in allowed.
+func filterEnv(env map[string]string, allowed []string) map[string]string {
+	allow := make(map[string]bool, len(allowed))
+	for _, k := range allowed {
+		allow[k] = true
+	}
+	out := make(map[string]string, len(allowed))
+	for k, v := range env {
+		if allow[k] {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// disallowedHosts returns the entries of declared that aren't present
+// in granted.
+func disallowedHosts(declared, granted []string) []string {
+	allow := make(map[string]bool, len(granted))
+	for _, h := range granted {
+		allow[h] = true
+	}
+	var extra []string
+	for _, h := range declared {
+		if !allow[h] {
+			extra = append(extra, h)
+		}
+	}
+	return extra
+}
+
+// seedPlugin loads the JSON snapshot at path and restores it into p,
+// returning an error if p doesn't implement sdk.Snapshotter.
+func seedPlugin(p sdk.Plugin, path string) error {
+	snapshotter, ok := p.(sdk.Snapshotter)
+	if !ok {
+		return fmt.Errorf("plugin does not support snapshotting")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading seed file: %w", err)
+	}
+	return snapshotter.Restore(data)
+}
+
+// buildFaultRule translates a service's static fault block into a
+// faults.Rule. Per-route overrides and rate limiting aren't expressible
+// in HCL here; they're set at runtime via POST /_/faults instead.
+func buildFaultRule(f *config.Fault) (faults.Rule, error) {
+	rule := faults.Rule{
+		ErrorRate: f.ErrorRate,
+		Status:    f.Status,
+		Body:      f.Body,
+		Truncate:  f.Truncate,
+		Drop:      f.Drop,
+	}
+	if f.Latency != "" {
+		d, err := time.ParseDuration(f.Latency)
+		if err != nil {
+			return faults.Rule{}, fmt.Errorf("parsing fault latency %q: %w", f.Latency, err)
+		}
+		rule.Latency = d
+	}
+	return rule, nil
+}
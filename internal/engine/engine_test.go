@@ -22,11 +22,18 @@ import (
 // fakePlugin is a minimal sdk.Plugin for testing.
 type fakePlugin struct {
 	info         sdk.PluginInfo
+	manifest     *sdk.PluginManifest // overrides the default Manifest() when set
 	configureErr error
 	resetErr     error
 }
 
-func (f *fakePlugin) Info() sdk.PluginInfo             { return f.info }
+func (f *fakePlugin) Info() sdk.PluginInfo { return f.info }
+func (f *fakePlugin) Manifest() sdk.PluginManifest {
+	if f.manifest != nil {
+		return *f.manifest
+	}
+	return sdk.PluginManifest{Name: f.info.Name, Version: f.info.Version, APIVersion: sdk.APIVersion}
+}
 func (f *fakePlugin) Configure(map[string]string) error { return f.configureErr }
 func (f *fakePlugin) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
@@ -199,3 +206,102 @@ func TestEngine_Run_ListenError(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "listen")
 }
+
+func TestAdmit_APIVersionMismatch(t *testing.T) {
+	manifest := sdk.PluginManifest{APIVersion: "99"}
+	_, err := admit(manifest, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API version")
+}
+
+func TestAdmit_MissingRequiredEnv(t *testing.T) {
+	manifest := sdk.PluginManifest{
+		APIVersion:  sdk.APIVersion,
+		RequiredEnv: []sdk.EnvVar{{Name: "API_KEY"}},
+	}
+	_, err := admit(manifest, nil, map[string]string{"OTHER": "x"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API_KEY")
+}
+
+func TestAdmit_GrantFiltersEnvAndChecksOutbound(t *testing.T) {
+	manifest := sdk.PluginManifest{
+		APIVersion:    sdk.APIVersion,
+		AllowOutbound: []string{"evil.example.com"},
+	}
+	grant := &config.Grant{
+		Type:          "github",
+		Env:           []string{"ALLOWED"},
+		AllowOutbound: []string{"api.github.com"},
+	}
+	_, err := admit(manifest, grant, map[string]string{"ALLOWED": "x", "SECRET": "y"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "evil.example.com")
+
+	manifest.AllowOutbound = []string{"api.github.com"}
+	env, err := admit(manifest, grant, map[string]string{"ALLOWED": "x", "SECRET": "y"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ALLOWED": "x"}, env)
+}
+
+func TestInstanceView_RedactsSecretEnv(t *testing.T) {
+	builtin.Registry["fakesecret"] = func() sdk.Plugin {
+		return &fakePlugin{
+			info: sdk.PluginInfo{Name: "fakesecret", Version: "v0"},
+			manifest: &sdk.PluginManifest{
+				APIVersion: sdk.APIVersion,
+				RequiredEnv: []sdk.EnvVar{
+					{Name: "API_TOKEN", Secret: true},
+					{Name: "ORG_NAME"},
+				},
+			},
+		}
+	}
+	defer delete(builtin.Registry, "fakesecret")
+
+	cfg := &config.Config{
+		Services: []config.Service{{
+			Type: "fakesecret",
+			Name: "s1",
+			Port: 8080,
+			Env:  map[string]string{"API_TOKEN": "shh", "ORG_NAME": "acme"},
+		}},
+	}
+	eng, err := New(cfg)
+	require.NoError(t, err)
+
+	view := eng.instanceView(eng.instances[0])
+	assert.Equal(t, redactedEnv, view.Env["API_TOKEN"])
+	assert.Equal(t, "acme", view.Env["ORG_NAME"])
+}
+
+func TestAdminHandler_RequiresToken(t *testing.T) {
+	eng, err := New(&config.Config{})
+	require.NoError(t, err)
+
+	handler := eng.AdminHandler(nil, "s3cr3t")
+
+	rec := doRequest(t, handler, http.MethodGet, "/plugins", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/plugins", nil)
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/plugins", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAdminHandler_NoTokenAllowsAccess(t *testing.T) {
+	eng, err := New(&config.Config{})
+	require.NoError(t, err)
+
+	handler := eng.AdminHandler(nil, "")
+	rec := doRequest(t, handler, http.MethodGet, "/plugins", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
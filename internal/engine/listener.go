@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/islo-labs/double-agent/internal/listener"
+)
+
+// listenFDsEnv names the environment variable a re-exec'd child reads
+// to learn which already-open listener fds a graceful upgrade handed
+// it, formatted as "addr=fd,addr=fd,...". Fds are passed as the child's
+// inherited files right after stdin/stdout/stderr, so they start
+// numbering at 3.
+const listenFDsEnv = "DOUBLE_LISTEN_FDS"
+
+// listenerStore hands out a net.Listener for each address an Engine
+// serves, adopting one inherited from a parent process via
+// listenFDsEnv when available and falling back to listener.Listen (tcp,
+// unix, tls, or systemd, per the address's scheme) otherwise.
+type listenerStore struct {
+	inherited map[string]*os.File
+}
+
+// newListenerStore parses listenFDsEnv, if set, into the addr->file
+// mapping a re-exec'd child inherits from its parent.
+func newListenerStore() *listenerStore {
+	s := &listenerStore{inherited: map[string]*os.File{}}
+	raw := os.Getenv(listenFDsEnv)
+	if raw == "" {
+		return s
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		addr, fdStr, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		fd, err := strconv.Atoi(fdStr)
+		if err != nil {
+			continue
+		}
+		s.inherited[addr] = os.NewFile(uintptr(fd), addr)
+	}
+	return s
+}
+
+// Listen returns a net.Listener for addr, adopting an inherited fd if a
+// parent process handed one over for this exact address, or parsing
+// addr's scheme (tcp, unix, tls, or systemd) and dialing a fresh one via
+// listener.Listen otherwise.
+func (s *listenerStore) Listen(addr string) (net.Listener, error) {
+	if f, ok := s.inherited[addr]; ok {
+		delete(s.inherited, addr)
+		ln, err := net.FileListener(f)
+		if err != nil {
+			return nil, fmt.Errorf("adopting inherited listener for %s: %w", addr, err)
+		}
+		return ln, nil
+	}
+	return listener.Listen(addr)
+}
+
+// listenerFile returns the *os.File backing ln, for handing a listener
+// off to a child process across a graceful upgrade.
+func listenerFile(ln net.Listener) (*os.File, error) {
+	type filer interface {
+		File() (*os.File, error)
+	}
+	f, ok := ln.(filer)
+	if !ok {
+		return nil, fmt.Errorf("listener %T does not support File()", ln)
+	}
+	return f.File()
+}
@@ -0,0 +1,68 @@
+// Package forge holds the concepts shared by every forge-style plugin
+// fake (github, gitlab, sourcehut, ...): repositories, tracker issues,
+// and pull/merge requests. Each plugin's Store composes these with
+// whatever is specific to its vendor, so adding another forge means
+// writing the differences rather than re-deriving the basics.
+package forge
+
+import "time"
+
+// Repo is a generic source repository.
+type Repo struct {
+	ID        int       `json:"id"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	FullName  string    `json:"full_name"`
+	Private   bool      `json:"private"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Issue is a generic tracker issue.
+type Issue struct {
+	ID        int       `json:"id"`
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Change is a generic pull/merge request: a proposed merge of one branch
+// into another.
+type Change struct {
+	ID        int       `json:"id"`
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	State     string    `json:"state"`
+	Head      string    `json:"head"`
+	Base      string    `json:"base"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IDAllocator hands out sequential integer ids. Every forge store needs
+// one of these for its repos/issues/changes, so it lives here instead of
+// being reimplemented per plugin.
+type IDAllocator struct {
+	next int
+}
+
+// NewIDAllocator returns an allocator whose first Next() is 1.
+func NewIDAllocator() *IDAllocator {
+	return &IDAllocator{next: 1}
+}
+
+// Next returns the next id and advances the counter.
+func (a *IDAllocator) Next() int {
+	id := a.next
+	a.next++
+	return id
+}
+
+// Reset restarts the counter at 1.
+func (a *IDAllocator) Reset() {
+	a.next = 1
+}
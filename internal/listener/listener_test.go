@@ -0,0 +1,87 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen_NoScheme(t *testing.T) {
+	_, err := Listen("localhost:8080")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no scheme")
+}
+
+func TestListen_UnknownScheme(t *testing.T) {
+	_, err := Listen("ftp://localhost:8080")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown scheme")
+}
+
+func TestListen_TCP(t *testing.T) {
+	ln, err := Listen("tcp://127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	assert.IsType(t, &net.TCPListener{}, ln)
+}
+
+func TestListen_Unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+	ln, err := Listen("unix://" + path)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.True(t, info.Mode()&os.ModeSocket != 0)
+}
+
+func TestListen_UnixAppliesMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+	ln, err := Listen("unix://" + path + "?mode=0600")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestListen_UnixRemovesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+	require.NoError(t, os.WriteFile(path, []byte("stale"), 0o644))
+
+	ln, err := Listen("unix://" + path)
+	require.NoError(t, err)
+	defer ln.Close()
+}
+
+func TestListen_UnixInvalidMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+	_, err := Listen("unix://" + path + "?mode=not-octal")
+	require.Error(t, err)
+}
+
+func TestListen_UnixUnknownOwner(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+	_, err := Listen("unix://" + path + "?owner=no-such-user-doubleagent-test")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "looking up owner")
+}
+
+func TestListen_TLSRequiresCertAndKey(t *testing.T) {
+	_, err := Listen("tls://127.0.0.1:0")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires cert and key")
+}
+
+func TestListen_SystemdRequiresActivation(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "")
+	_, err := Listen("systemd://web")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "was not socket-activated")
+}
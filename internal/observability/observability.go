@@ -0,0 +1,173 @@
+// Package observability wires request tracing, Prometheus metrics, and
+// structured access logs into every plugin instance's mux, the same way
+// webhook dispatch and fault injection hang off an Instance rather than
+// the plugin implementations themselves: plugin authors get it for
+// free, and doubleagent users testing distributed systems against its
+// fakes see end-to-end traces by propagating W3C traceparent headers to
+// a record-mode upstream. Webhook deliveries carry no trace context.
+package observability
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/islo-labs/double-agent/internal/config"
+)
+
+const defaultServiceName = "double-agent"
+
+// Provider is the engine-wide tracer, metrics registry, and propagator
+// every Instance's Middleware and record-mode upstream proxying share.
+type Provider struct {
+	tracer     trace.Tracer
+	tp         *sdktrace.TracerProvider
+	propagator propagation.TextMapPropagator
+
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// New builds a Provider from cfg, which may be nil (the same as an
+// empty config.Observability): spans and metrics are always recorded
+// in-process; cfg.OTLPEndpoint additionally batches spans out to a
+// collector.
+func New(cfg *config.Observability) (*Provider, error) {
+	name := defaultServiceName
+	var endpoint string
+	if cfg != nil {
+		if cfg.ServiceName != "" {
+			name = cfg.ServiceName
+		}
+		endpoint = cfg.OTLPEndpoint
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", name),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("building observability resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if endpoint != "" {
+		exp, err := otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpoint(endpoint),
+			otlptracehttp.WithInsecure(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("building otlp exporter for %s: %w", endpoint, err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	}
+	tp := sdktrace.NewTracerProvider(opts...)
+
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "doubleagent_http_requests_total",
+		Help: "Total HTTP requests served by each plugin instance.",
+	}, []string{"plugin_type", "plugin_name", "route", "status"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "doubleagent_http_request_duration_seconds",
+		Help:    "Request latency per plugin instance.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"plugin_type", "plugin_name", "route"})
+	registry.MustRegister(requests, duration)
+
+	return &Provider{
+		tracer:     tp.Tracer("github.com/islo-labs/double-agent"),
+		tp:         tp,
+		propagator: propagation.TraceContext{},
+		registry:   registry,
+		requests:   requests,
+		duration:   duration,
+	}, nil
+}
+
+// Shutdown flushes and stops the span exporter. Callers should give it
+// the same context.Background() they use for http.Server.Shutdown.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.tp.Shutdown(ctx)
+}
+
+// MetricsHandler serves the Prometheus text exposition format for every
+// metric this Provider records.
+func (p *Provider) MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware wraps next with request tracing, metrics, and a structured
+// access log for one plugin instance, identified by pluginType and
+// pluginName (the same labels engine.New uses elsewhere for a
+// service). Inbound traceparent headers are extracted so a caller's own
+// trace continues through the fake rather than starting a new one.
+func (p *Provider) Middleware(pluginType, pluginName string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := p.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := p.tracer.Start(ctx, pluginType+"."+pluginName+" "+r.Method+" "+r.URL.Path,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("doubleagent.plugin.type", pluginType),
+					attribute.String("doubleagent.plugin.name", pluginName),
+					attribute.String("http.method", r.Method),
+					attribute.String("http.route", r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			w.Header().Set("X-Request-Id", span.SpanContext().TraceID().String())
+
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+			elapsed := time.Since(start)
+
+			status := fmt.Sprintf("%d", rec.status)
+			span.SetAttributes(attribute.Int("http.status_code", rec.status))
+			if rec.status >= 500 {
+				span.SetStatus(codes.Error, http.StatusText(rec.status))
+			}
+
+			p.requests.WithLabelValues(pluginType, pluginName, r.URL.Path, status).Inc()
+			p.duration.WithLabelValues(pluginType, pluginName, r.URL.Path).Observe(elapsed.Seconds())
+
+			log.Printf(`access type=%s name=%s method=%s path=%s status=%d duration_ms=%d trace_id=%s`,
+				pluginType, pluginName, r.Method, r.URL.Path, rec.status, elapsed.Milliseconds(), span.SpanContext().TraceID())
+		})
+	}
+}
+
+// Propagate injects the trace context carried by ctx into req's
+// headers, so a downstream call a fake makes on behalf of an inbound
+// request (record-mode upstream proxying) continues the same trace
+// instead of starting an unlinked one.
+func (p *Provider) Propagate(ctx context.Context, req *http.Request) {
+	p.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
+
+// statusRecorder captures the status code a handler wrote, since
+// http.ResponseWriter doesn't expose it otherwise.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
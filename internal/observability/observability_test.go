@@ -0,0 +1,72 @@
+package observability
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_RecordsSpanMetricsAndRequestID(t *testing.T) {
+	r := NewRecorder()
+	defer r.Reset()
+
+	h := r.Middleware("jira", "primary")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/rest/api/2/issue", nil))
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.NotEmpty(t, w.Header().Get("X-Request-Id"))
+	r.AssertSpan(t, "jira.primary GET /rest/api/2/issue")
+	r.AssertRequestCount(t, "jira", "primary", "/rest/api/2/issue", "201", 1)
+}
+
+func TestMiddleware_DefaultStatusIsOK(t *testing.T) {
+	r := NewRecorder()
+	defer r.Reset()
+
+	h := r.Middleware("gitlab", "primary")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok")) // no explicit WriteHeader: defaults to 200
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	r.AssertRequestCount(t, "gitlab", "primary", "/", "200", 1)
+}
+
+func TestMiddleware_PropagatesInboundTraceparent(t *testing.T) {
+	r := NewRecorder()
+	defer r.Reset()
+
+	h := r.Middleware("jira", "primary")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	spans := r.exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext.TraceID().String())
+}
+
+func TestPropagate_InjectsTraceparentHeader(t *testing.T) {
+	r := NewRecorder()
+	defer r.Reset()
+
+	ctx, span := r.tracer.Start(httptest.NewRequest(http.MethodGet, "/", nil).Context(), "outbound")
+	defer span.End()
+
+	req := httptest.NewRequest(http.MethodGet, "http://upstream/", nil)
+	r.Propagate(ctx, req)
+
+	assert.NotEmpty(t, req.Header.Get("traceparent"))
+}
@@ -0,0 +1,66 @@
+package observability
+
+import (
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Recorder is a Provider backed by an in-memory span exporter, for
+// plugin authors to assert their fake was actually exercised the way
+// they expect without standing up a real collector.
+type Recorder struct {
+	*Provider
+	exporter *tracetest.InMemoryExporter
+}
+
+// NewRecorder builds a Recorder with no OTLP endpoint. Use its embedded
+// Provider's Middleware and Propagate directly; spans land only in the
+// in-memory exporter.
+func NewRecorder() *Recorder {
+	exporter := tracetest.NewInMemoryExporter()
+	p, _ := New(nil)
+	p.tp = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	p.tracer = p.tp.Tracer("github.com/islo-labs/double-agent")
+	return &Recorder{Provider: p, exporter: exporter}
+}
+
+// Reset discards every recorded span, for reuse across subtests.
+func (r *Recorder) Reset() {
+	r.exporter.Reset()
+}
+
+// AssertSpan fails t unless a span named name was recorded.
+func (r *Recorder) AssertSpan(t *testing.T, name string) {
+	t.Helper()
+	for _, s := range r.exporter.GetSpans() {
+		if s.Name == name {
+			return
+		}
+	}
+	t.Errorf("observability: no span named %q recorded (have: %v)", name, r.spanNames())
+}
+
+func (r *Recorder) spanNames() []string {
+	spans := r.exporter.GetSpans()
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// AssertRequestCount fails t unless exactly want requests matching
+// pluginType/pluginName/route/status were recorded in
+// doubleagent_http_requests_total.
+func (r *Recorder) AssertRequestCount(t *testing.T, pluginType, pluginName, route, status string, want float64) {
+	t.Helper()
+	got := testutil.ToFloat64(r.requests.WithLabelValues(pluginType, pluginName, route, status))
+	if got != want {
+		t.Errorf("observability: doubleagent_http_requests_total{plugin_type=%q,plugin_name=%q,route=%q,status=%q} = %v, want %v",
+			pluginType, pluginName, route, status, got, want)
+	}
+}
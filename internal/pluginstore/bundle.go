@@ -0,0 +1,339 @@
+package pluginstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/islo-labs/double-agent/pkg/sdk"
+)
+
+// Signature names the key material to verify a Service.Plugin bundle
+// with, beyond its content digest.
+type Signature struct {
+	Type      string // "cosign" or "minisign"
+	PublicKey string
+}
+
+// bundleManifestFile is the name of the sdk.PluginManifest JSON file a
+// bundle carries, the same convention Push expects of a directory
+// uploaded as an OCI artifact.
+const bundleManifestFile = "manifest.json"
+
+// CacheRoot returns $XDG_CACHE_HOME/double-agent/plugins, creating it
+// if necessary, falling back to os.UserCacheDir when XDG_CACHE_HOME is
+// unset.
+func CacheRoot() (string, error) {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("resolving cache dir: %w", err)
+		}
+		base = dir
+	}
+	root := filepath.Join(base, "double-agent", "plugins")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", fmt.Errorf("creating plugin cache dir: %w", err)
+	}
+	return root, nil
+}
+
+// FetchBundle resolves ref - an "oci://registry/repo@sha256:..." or
+// "https://host/path/bundle.tar.zst#sha256=..." content-addressable
+// plugin bundle - into the command internal/supervisor should launch
+// and the sdk.PluginManifest it declares, verifying sig if given. The
+// bundle is cached under CacheRoot()/<digest>/, so a second service
+// referencing the same ref reuses the already-verified, already-
+// extracted copy rather than fetching and re-verifying it.
+func FetchBundle(ref string, sig *Signature) ([]string, sdk.PluginManifest, error) {
+	root, err := CacheRoot()
+	if err != nil {
+		return nil, sdk.PluginManifest{}, err
+	}
+
+	var (
+		manifest   sdk.PluginManifest
+		installDir string
+	)
+	if strings.HasPrefix(ref, "https://") {
+		manifest, installDir, err = fetchHTTPBundle(ref, sig, root)
+	} else {
+		manifest, installDir, err = fetchOCIBundle(ref, sig, root)
+	}
+	if err != nil {
+		return nil, sdk.PluginManifest{}, err
+	}
+	if len(manifest.Entrypoint) == 0 {
+		return nil, sdk.PluginManifest{}, fmt.Errorf("bundle %s manifest has no entrypoint", ref)
+	}
+
+	command := append([]string{}, manifest.Entrypoint...)
+	if !filepath.IsAbs(command[0]) {
+		command[0] = filepath.Join(installDir, command[0])
+	}
+	return command, manifest, nil
+}
+
+// fetchOCIBundle pulls ref - which must be digest-pinned, since a
+// floating tag isn't content-addressable - the same way Store.Install
+// does, but caches it under root/<digest>/ instead of a PluginDir's
+// alias index.
+func fetchOCIBundle(ref string, sig *Signature, root string) (sdk.PluginManifest, string, error) {
+	parsed, err := ParseReference(ref)
+	if err != nil {
+		return sdk.PluginManifest{}, "", err
+	}
+	if parsed.Digest == "" {
+		return sdk.PluginManifest{}, "", fmt.Errorf("oci plugin ref %q must be pinned by digest (repo@sha256:...)", ref)
+	}
+
+	if sig != nil && sig.Type == "cosign" {
+		// cosign verifies an OCI ref against the registry directly,
+		// fetching whatever signature manifest accompanies it itself.
+		// This runs unconditionally, even on a cache hit below: the
+		// cache key is only the content digest, so a bundle installed
+		// once with no signature (or a weaker one) configured must
+		// still be checked against sig before we trust it again.
+		if err := verifyCosignOCI(ref, sig.PublicKey); err != nil {
+			return sdk.PluginManifest{}, "", fmt.Errorf("verifying bundle signature: %w", err)
+		}
+	} else if sig != nil {
+		return sdk.PluginManifest{}, "", fmt.Errorf("signature type %q is not supported for oci:// bundles", sig.Type)
+	}
+
+	installDir := filepath.Join(root, strings.ReplaceAll(parsed.Digest, ":", "-"))
+	if manifest, ok := cachedManifest(installDir); ok {
+		return manifest, installDir, nil
+	}
+
+	client := newRegistryClient()
+	om, _, err := client.pullManifest(parsed)
+	if err != nil {
+		return sdk.PluginManifest{}, "", err
+	}
+	configBlob, err := client.pullBlob(parsed, om.Config)
+	if err != nil {
+		return sdk.PluginManifest{}, "", fmt.Errorf("pulling plugin manifest: %w", err)
+	}
+	manifest, err := decodeManifestConfig(configBlob)
+	if err != nil {
+		return sdk.PluginManifest{}, "", err
+	}
+	if len(om.Layers) == 0 {
+		return sdk.PluginManifest{}, "", fmt.Errorf("bundle %s has no layers", ref)
+	}
+
+	if err := os.RemoveAll(installDir); err != nil {
+		return sdk.PluginManifest{}, "", fmt.Errorf("clearing install dir: %w", err)
+	}
+	if err := os.MkdirAll(installDir, 0o755); err != nil {
+		return sdk.PluginManifest{}, "", fmt.Errorf("creating install dir: %w", err)
+	}
+	for _, layer := range om.Layers {
+		blob, err := client.pullBlob(parsed, layer)
+		if err != nil {
+			return sdk.PluginManifest{}, "", fmt.Errorf("pulling layer %s: %w", layer.Digest, err)
+		}
+		if err := unpackLayer(blob, installDir); err != nil {
+			return sdk.PluginManifest{}, "", fmt.Errorf("unpacking layer %s: %w", layer.Digest, err)
+		}
+	}
+	if err := writeCachedManifest(installDir, manifest); err != nil {
+		return sdk.PluginManifest{}, "", err
+	}
+	return manifest, installDir, nil
+}
+
+// fetchHTTPBundle downloads the zstd-compressed tarball named by ref,
+// verifying it against the "sha256=..." digest carried in its URL
+// fragment (and sig, if given) before extracting it. The tarball must
+// contain a manifest.json at its root, the same layout Push expects of
+// a directory pushed as an OCI artifact.
+func fetchHTTPBundle(ref string, sig *Signature, root string) (sdk.PluginManifest, string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return sdk.PluginManifest{}, "", fmt.Errorf("parsing bundle url %q: %w", ref, err)
+	}
+	digest := strings.TrimPrefix(u.Fragment, "sha256=")
+	if digest == "" {
+		return sdk.PluginManifest{}, "", fmt.Errorf("bundle url %q has no #sha256=... digest", ref)
+	}
+	u.Fragment = ""
+
+	resp, err := http.Get(u.String())
+	if err != nil {
+		return sdk.PluginManifest{}, "", fmt.Errorf("fetching bundle %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return sdk.PluginManifest{}, "", fmt.Errorf("fetching bundle %s: unexpected status %s", u, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return sdk.PluginManifest{}, "", fmt.Errorf("reading bundle %s: %w", u, err)
+	}
+	if got := strings.TrimPrefix(digestOf(data), "sha256:"); got != digest {
+		return sdk.PluginManifest{}, "", fmt.Errorf("bundle %s: digest mismatch: expected %s, got %s", u, digest, got)
+	}
+	if sig != nil {
+		if err := verifyDetachedSignature(ref, data, sig); err != nil {
+			return sdk.PluginManifest{}, "", fmt.Errorf("verifying bundle signature: %w", err)
+		}
+	}
+
+	// The cache check happens only after the digest and any required
+	// signature have been verified against the bytes just fetched: the
+	// cache key is only the content digest, so a bundle cached once
+	// with no signature configured must not let a later call that does
+	// require one skip straight to the unverified cached extraction.
+	installDir := filepath.Join(root, "sha256-"+digest)
+	if manifest, ok := cachedManifest(installDir); ok {
+		return manifest, installDir, nil
+	}
+
+	zr, err := zstd.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return sdk.PluginManifest{}, "", fmt.Errorf("opening zstd stream: %w", err)
+	}
+	defer zr.Close()
+
+	if err := os.RemoveAll(installDir); err != nil {
+		return sdk.PluginManifest{}, "", fmt.Errorf("clearing install dir: %w", err)
+	}
+	if err := os.MkdirAll(installDir, 0o755); err != nil {
+		return sdk.PluginManifest{}, "", fmt.Errorf("creating install dir: %w", err)
+	}
+	if err := extractTar(zr, installDir); err != nil {
+		return sdk.PluginManifest{}, "", fmt.Errorf("extracting bundle: %w", err)
+	}
+
+	manifestData, err := os.ReadFile(filepath.Join(installDir, bundleManifestFile))
+	if err != nil {
+		return sdk.PluginManifest{}, "", fmt.Errorf("bundle %s missing %s: %w", ref, bundleManifestFile, err)
+	}
+	manifest, err := decodeManifestConfig(manifestData)
+	if err != nil {
+		return sdk.PluginManifest{}, "", err
+	}
+	return manifest, installDir, nil
+}
+
+// cachedManifest reads back the manifest.json a previous fetch of the
+// same digest left in installDir, reporting ok=false if it's not there
+// (a fresh digest, or a partially-cleaned-up install).
+func cachedManifest(installDir string) (sdk.PluginManifest, bool) {
+	data, err := os.ReadFile(filepath.Join(installDir, bundleManifestFile))
+	if err != nil {
+		return sdk.PluginManifest{}, false
+	}
+	manifest, err := decodeManifestConfig(data)
+	if err != nil {
+		return sdk.PluginManifest{}, false
+	}
+	return manifest, true
+}
+
+// writeCachedManifest persists manifest as installDir's manifest.json,
+// so the next FetchBundle for the same digest can skip straight to
+// cachedManifest instead of re-pulling and re-verifying. Only needed
+// for OCI bundles, whose manifest is a separate config blob rather than
+// part of the extracted layer tree.
+func writeCachedManifest(installDir string, manifest sdk.PluginManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling bundle manifest: %w", err)
+	}
+	return os.WriteFile(filepath.Join(installDir, bundleManifestFile), data, 0o644)
+}
+
+// verifyCosignOCI shells out to `cosign verify`, which fetches and
+// checks the signature manifest a registry stores alongside ref
+// itself; doubleagent doesn't reimplement the Sigstore verification
+// protocol.
+func verifyCosignOCI(ref, publicKey string) error {
+	out, err := exec.Command("cosign", "verify", "--key", publicKey, strings.TrimPrefix(ref, "oci://")).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cosign verify %s: %w: %s", ref, err, out)
+	}
+	return nil
+}
+
+// verifyDetachedSignature verifies data against a signature fetched
+// from alongside ref (ref+".sig" for cosign, ref+".minisig" for
+// minisign - the same sidecar convention both tools' own CLIs default
+// to), shelling out to the matching verifier.
+func verifyDetachedSignature(ref string, data []byte, sig *Signature) error {
+	var suffix string
+	var cmdFor func(dataFile, sigFile string) *exec.Cmd
+	switch sig.Type {
+	case "cosign":
+		suffix = ".sig"
+		cmdFor = func(dataFile, sigFile string) *exec.Cmd {
+			return exec.Command("cosign", "verify-blob", "--key", sig.PublicKey, "--signature", sigFile, dataFile)
+		}
+	case "minisign":
+		suffix = ".minisig"
+		cmdFor = func(dataFile, sigFile string) *exec.Cmd {
+			return exec.Command("minisign", "-V", "-p", sig.PublicKey, "-m", dataFile, "-x", sigFile)
+		}
+	default:
+		return fmt.Errorf("unknown signature type %q", sig.Type)
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return err
+	}
+	u.Fragment = ""
+	sigResp, err := http.Get(u.String() + suffix)
+	if err != nil {
+		return fmt.Errorf("fetching signature %s: %w", u.String()+suffix, err)
+	}
+	defer sigResp.Body.Close()
+	if sigResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching signature %s: unexpected status %s", u.String()+suffix, sigResp.Status)
+	}
+	sigData, err := io.ReadAll(sigResp.Body)
+	if err != nil {
+		return err
+	}
+
+	dataFile, err := writeTemp("doubleagent-bundle-*", data)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dataFile)
+	sigFile, err := writeTemp("doubleagent-bundle-*"+suffix, sigData)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(sigFile)
+
+	out, err := cmdFor(dataFile, sigFile).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("signature verification failed: %w: %s", err, out)
+	}
+	return nil
+}
+
+func writeTemp(pattern string, data []byte) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return "", fmt.Errorf("writing temp file: %w", err)
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,128 @@
+package pluginstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/islo-labs/double-agent/pkg/sdk"
+)
+
+// TestFetchOCIBundle_VerifiesDigestPin confirms fetchOCIBundle inherits
+// pullManifest's digest verification: a review comment flagged that it
+// pulled an oci:// bundle through the same unverified path Store.Install
+// originally had, so a pinned digest gave no real integrity guarantee.
+func TestFetchOCIBundle_VerifiesDigestPin(t *testing.T) {
+	pm := sdk.PluginManifest{Name: "acme", Version: "v1", Entrypoint: []string{"run"}}
+	configBlob, err := json.Marshal(pm)
+	require.NoError(t, err)
+	configDigest := digestOf(configBlob)
+
+	layerBlob := emptyGzippedTar(t)
+	layerDigest := digestOf(layerBlob)
+
+	manifest := ociManifest{
+		SchemaVersion: 2,
+		Config:        descriptor{MediaType: mediaTypeConfig, Digest: configDigest, Size: int64(len(configBlob))},
+		Layers:        []descriptor{{MediaType: mediaTypeLayer, Digest: layerDigest, Size: int64(len(layerBlob))}},
+	}
+	manifestBody, err := json.Marshal(manifest)
+	require.NoError(t, err)
+	manifestDigest := digestOf(manifestBody)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/manifests/"):
+			w.Write(manifestBody)
+		case strings.HasSuffix(r.URL.Path, configDigest):
+			w.Write(configBlob)
+		case strings.HasSuffix(r.URL.Path, layerDigest):
+			w.Write(layerBlob)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	prev := http.DefaultClient
+	http.DefaultClient = ts.Client()
+	defer func() { http.DefaultClient = prev }()
+
+	host := strings.TrimPrefix(ts.URL, "https://")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	badDigest := strings.Repeat("0", len(strings.TrimPrefix(manifestDigest, "sha256:")))
+	_, _, err = FetchBundle(fmt.Sprintf("oci://%s/acme/fake@sha256:%s", host, badDigest), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "digest mismatch")
+
+	command, gotManifest, err := FetchBundle(fmt.Sprintf("oci://%s/acme/fake@%s", host, manifestDigest), nil)
+	require.NoError(t, err)
+	assert.Equal(t, pm.Name, gotManifest.Name)
+	require.Len(t, command, 1)
+	assert.True(t, strings.HasSuffix(command[0], "run"))
+}
+
+// TestFetchOCIBundle_CacheDoesNotBypassSignatureVerification is the
+// regression test for a review comment: fetchOCIBundle used to check
+// cachedManifest before verifying sig, so a bundle fetched once with no
+// Signature configured cached its manifest unverified, and a later
+// FetchBundle call for the same digest that does require a signature
+// would silently hand back the cached manifest instead of verifying it.
+func TestFetchOCIBundle_CacheDoesNotBypassSignatureVerification(t *testing.T) {
+	pm := sdk.PluginManifest{Name: "acme", Version: "v1", Entrypoint: []string{"run"}}
+	configBlob, err := json.Marshal(pm)
+	require.NoError(t, err)
+	configDigest := digestOf(configBlob)
+
+	layerBlob := emptyGzippedTar(t)
+	layerDigest := digestOf(layerBlob)
+
+	manifest := ociManifest{
+		SchemaVersion: 2,
+		Config:        descriptor{MediaType: mediaTypeConfig, Digest: configDigest, Size: int64(len(configBlob))},
+		Layers:        []descriptor{{MediaType: mediaTypeLayer, Digest: layerDigest, Size: int64(len(layerBlob))}},
+	}
+	manifestBody, err := json.Marshal(manifest)
+	require.NoError(t, err)
+	manifestDigest := digestOf(manifestBody)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/manifests/"):
+			w.Write(manifestBody)
+		case strings.HasSuffix(r.URL.Path, configDigest):
+			w.Write(configBlob)
+		case strings.HasSuffix(r.URL.Path, layerDigest):
+			w.Write(layerBlob)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	prev := http.DefaultClient
+	http.DefaultClient = ts.Client()
+	defer func() { http.DefaultClient = prev }()
+
+	host := strings.TrimPrefix(ts.URL, "https://")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	ref := fmt.Sprintf("oci://%s/acme/fake@%s", host, manifestDigest)
+
+	// Prime the cache with no signature required.
+	_, _, err = FetchBundle(ref, nil)
+	require.NoError(t, err)
+
+	// A later fetch of the same digest that does require a signature
+	// must still run signature verification rather than returning the
+	// manifest cached by the unsigned fetch above.
+	_, _, err = FetchBundle(ref, &Signature{Type: "minisign", PublicKey: "irrelevant"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported for oci:// bundles")
+}
@@ -0,0 +1,210 @@
+package pluginstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/islo-labs/double-agent/pkg/sdk"
+)
+
+// These media types mirror the OCI image-spec; double-agent doesn't need
+// the full spec, only enough of it to round-trip a plugin manifest and
+// its binary through a standard registry.
+const (
+	mediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeConfig   = "application/vnd.doubleagent.plugin.config.v1+json"
+	mediaTypeLayer    = "application/vnd.doubleagent.plugin.layer.v1.tar+gzip"
+)
+
+// descriptor is an OCI content descriptor: a reference to a blob by its
+// digest, media type, and size.
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// ociManifest is the subset of the OCI image manifest double-agent reads
+// and writes: a config blob (the sdk.PluginManifest) plus one or more
+// layers (the plugin's binary/rootfs).
+type ociManifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        descriptor   `json:"config"`
+	Layers        []descriptor `json:"layers"`
+}
+
+// digestOf returns the OCI-style "sha256:<hex>" digest of data.
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// verifyDigest reports whether data matches the claimed digest.
+func verifyDigest(data []byte, digest string) error {
+	got := digestOf(data)
+	if got != digest {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", digest, got)
+	}
+	return nil
+}
+
+// registryClient pulls and pushes OCI artifacts over the standard
+// distribution HTTP API (/v2/...). It's unauthenticated; registries that
+// require auth aren't supported yet.
+type registryClient struct {
+	client *http.Client
+}
+
+func newRegistryClient() *registryClient {
+	return &registryClient{client: http.DefaultClient}
+}
+
+func (c *registryClient) baseURL(ref Reference) string {
+	return fmt.Sprintf("https://%s/v2/%s", ref.Registry, ref.Repository)
+}
+
+// pullManifest fetches and parses the manifest for ref, by digest if
+// ref.Digest is set (pinning the exact artifact), by tag otherwise. It
+// returns the digest of the bytes actually received, verified against
+// ref.Digest when the caller pinned one, so a compromised or MITM'd
+// registry can't substitute a different manifest for a digest-pinned
+// pull; callers use this verified digest as the content-address key
+// instead of re-deriving one from the parsed struct.
+func (c *registryClient) pullManifest(ref Reference) (ociManifest, string, error) {
+	tagOrDigest := ref.Tag
+	if ref.Digest != "" {
+		tagOrDigest = ref.Digest
+	}
+	url := fmt.Sprintf("%s/manifests/%s", c.baseURL(ref), tagOrDigest)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return ociManifest{}, "", err
+	}
+	req.Header.Set("Accept", mediaTypeManifest)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return ociManifest{}, "", fmt.Errorf("fetching manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ociManifest{}, "", fmt.Errorf("fetching manifest %s: unexpected status %s", ref, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ociManifest{}, "", fmt.Errorf("reading manifest %s: %w", ref, err)
+	}
+	if ref.Digest != "" {
+		if err := verifyDigest(body, ref.Digest); err != nil {
+			return ociManifest{}, "", fmt.Errorf("manifest %s: %w", ref, err)
+		}
+	}
+	var m ociManifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return ociManifest{}, "", fmt.Errorf("decoding manifest: %w", err)
+	}
+	return m, digestOf(body), nil
+}
+
+// pullBlob fetches the blob named by d and verifies it against d's
+// claimed digest and size before returning it.
+func (c *registryClient) pullBlob(ref Reference, d descriptor) ([]byte, error) {
+	url := fmt.Sprintf("%s/blobs/%s", c.baseURL(ref), d.Digest)
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching blob %s: %w", d.Digest, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching blob %s: unexpected status %s", d.Digest, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading blob %s: %w", d.Digest, err)
+	}
+	if d.Size > 0 && int64(len(data)) != d.Size {
+		return nil, fmt.Errorf("blob %s: expected %d bytes, got %d", d.Digest, d.Size, len(data))
+	}
+	if err := verifyDigest(data, d.Digest); err != nil {
+		return nil, fmt.Errorf("blob %s: %w", d.Digest, err)
+	}
+	return data, nil
+}
+
+// pushBlob uploads data as a monolithic blob and returns its descriptor.
+// It follows the distribution spec's POST-then-PUT upload flow.
+func (c *registryClient) pushBlob(ref Reference, mediaType string, data []byte) (descriptor, error) {
+	digest := digestOf(data)
+
+	startReq, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/blobs/uploads/", c.baseURL(ref)), nil)
+	if err != nil {
+		return descriptor{}, err
+	}
+	startResp, err := c.client.Do(startReq)
+	if err != nil {
+		return descriptor{}, fmt.Errorf("starting blob upload: %w", err)
+	}
+	location := startResp.Header.Get("Location")
+	startResp.Body.Close()
+	if startResp.StatusCode != http.StatusAccepted || location == "" {
+		return descriptor{}, fmt.Errorf("starting blob upload: unexpected status %s", startResp.Status)
+	}
+
+	putURL := location
+	if bytes.ContainsRune([]byte(location), '?') {
+		putURL += "&digest=" + digest
+	} else {
+		putURL += "?digest=" + digest
+	}
+	putReq, err := http.NewRequest(http.MethodPut, putURL, bytes.NewReader(data))
+	if err != nil {
+		return descriptor{}, err
+	}
+	putReq.Header.Set("Content-Type", "application/octet-stream")
+	putResp, err := c.client.Do(putReq)
+	if err != nil {
+		return descriptor{}, fmt.Errorf("uploading blob: %w", err)
+	}
+	defer putResp.Body.Close()
+	if putResp.StatusCode != http.StatusCreated {
+		return descriptor{}, fmt.Errorf("uploading blob: unexpected status %s", putResp.Status)
+	}
+	return descriptor{MediaType: mediaType, Digest: digest, Size: int64(len(data))}, nil
+}
+
+// pushManifest uploads m as ref's manifest.
+func (c *registryClient) pushManifest(ref Reference, m ociManifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshaling manifest: %w", err)
+	}
+	url := fmt.Sprintf("%s/manifests/%s", c.baseURL(ref), ref.Tag)
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mediaTypeManifest)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pushing manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("pushing manifest: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// decodeManifestConfig unmarshals a config blob into an sdk.PluginManifest.
+func decodeManifestConfig(data []byte) (sdk.PluginManifest, error) {
+	var pm sdk.PluginManifest
+	if err := json.Unmarshal(data, &pm); err != nil {
+		return sdk.PluginManifest{}, fmt.Errorf("decoding plugin manifest: %w", err)
+	}
+	return pm, nil
+}
@@ -0,0 +1,63 @@
+package pluginstore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPullManifest_VerifiesDigest is the regression test for the bug a
+// review caught: pullManifest fetched "/manifests/<digest>" but never
+// checked the response against the digest it pinned, so a compromised
+// or MITM'd registry could return any manifest it wanted for that URL.
+func TestPullManifest_VerifiesDigest(t *testing.T) {
+	manifestBody := []byte(`{"schemaVersion":2,"config":{"digest":"sha256:deadbeef"},"layers":[]}`)
+	digest := digestOf(manifestBody)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(manifestBody)
+	}))
+	defer ts.Close()
+
+	client := &registryClient{client: ts.Client()}
+	ref := Reference{Registry: strings.TrimPrefix(ts.URL, "https://"), Repository: "acme/fake", Digest: digest}
+
+	_, got, err := client.pullManifest(ref)
+	require.NoError(t, err)
+	assert.Equal(t, digest, got)
+
+	// A caller pinning a digest the server doesn't actually serve must
+	// be refused, not silently handed whatever came back.
+	ref.Digest = digestOf([]byte("something else entirely"))
+	_, _, err = client.pullManifest(ref)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "digest mismatch")
+}
+
+// TestPullManifest_ReturnsReceivedDigestForTags exercises the tag path
+// (no pin to verify against), confirming the returned digest is still
+// derived from the bytes actually received rather than a re-marshal of
+// the parsed struct - the same content-address key Store.Install now
+// uses instead of computing its own.
+func TestPullManifest_ReturnsReceivedDigestForTags(t *testing.T) {
+	manifest := ociManifest{SchemaVersion: 2, Config: descriptor{Digest: "sha256:deadbeef"}}
+	manifestBody, err := json.Marshal(manifest)
+	require.NoError(t, err)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(manifestBody)
+	}))
+	defer ts.Close()
+
+	client := &registryClient{client: ts.Client()}
+	ref := Reference{Registry: strings.TrimPrefix(ts.URL, "https://"), Repository: "acme/fake", Tag: "latest"}
+
+	_, got, err := client.pullManifest(ref)
+	require.NoError(t, err)
+	assert.Equal(t, digestOf(manifestBody), got)
+}
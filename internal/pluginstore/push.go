@@ -0,0 +1,113 @@
+package pluginstore
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// manifestFile is the name of the sdk.PluginManifest JSON file expected
+// at the root of a directory passed to Push.
+const manifestFile = "manifest.json"
+
+// Push builds an OCI artifact from dir (which must contain a
+// manifest.json matching sdk.PluginManifest at its root, alongside the
+// plugin binary and any supporting files) and uploads it to ref: the
+// rest of dir becomes a single gzip-compressed tar layer, manifest.json
+// becomes the config blob, and both are referenced by a pushed manifest.
+func Push(dir string, ref string) error {
+	parsed, err := ParseReference(ref)
+	if err != nil {
+		return err
+	}
+
+	configBlob, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", manifestFile, err)
+	}
+	if _, err := decodeManifestConfig(configBlob); err != nil {
+		return err
+	}
+
+	layerBlob, err := tarGzDir(dir, manifestFile)
+	if err != nil {
+		return fmt.Errorf("building layer: %w", err)
+	}
+
+	client := newRegistryClient()
+	configDesc, err := client.pushBlob(parsed, mediaTypeConfig, configBlob)
+	if err != nil {
+		return fmt.Errorf("pushing config blob: %w", err)
+	}
+	layerDesc, err := client.pushBlob(parsed, mediaTypeLayer, layerBlob)
+	if err != nil {
+		return fmt.Errorf("pushing layer blob: %w", err)
+	}
+
+	manifest := ociManifest{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeManifest,
+		Config:        configDesc,
+		Layers:        []descriptor{layerDesc},
+	}
+	return client.pushManifest(parsed, manifest)
+}
+
+// tarGzDir archives every file under dir except skip (matched by base
+// name, at dir's root only) into a gzip-compressed tar, with paths
+// relative to dir.
+func tarGzDir(dir string, skip string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." || rel == skip {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = rel
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,63 @@
+package pluginstore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reference identifies an OCI artifact, e.g. "ghcr.io/acme/stripe-fake:v1"
+// or, pinned by digest, "ghcr.io/acme/stripe-fake@sha256:...".
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	// Digest, if set, pins the reference to one manifest and takes
+	// precedence over Tag when pulling.
+	Digest string
+}
+
+// String returns the canonical form: "registry/repository@digest" if
+// Digest is set, "registry/repository:tag" otherwise.
+func (r Reference) String() string {
+	if r.Digest != "" {
+		return fmt.Sprintf("%s/%s@%s", r.Registry, r.Repository, r.Digest)
+	}
+	return fmt.Sprintf("%s/%s:%s", r.Registry, r.Repository, r.Tag)
+}
+
+// ParseReference parses an OCI image reference of the form
+// "[oci://]registry/repository[:tag]" or
+// "[oci://]registry/repository@sha256:...", defaulting Tag to "latest"
+// if neither a tag nor a digest is given.
+func ParseReference(ref string) (Reference, error) {
+	ref = strings.TrimPrefix(ref, "oci://")
+	if ref == "" {
+		return Reference{}, fmt.Errorf("empty reference")
+	}
+	slash := strings.Index(ref, "/")
+	if slash < 0 {
+		return Reference{}, fmt.Errorf("reference %q has no registry host", ref)
+	}
+	registry := ref[:slash]
+	rest := ref[slash+1:]
+	if rest == "" {
+		return Reference{}, fmt.Errorf("reference %q has no repository", ref)
+	}
+
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		repository, digest := rest[:at], rest[at+1:]
+		if repository == "" || digest == "" {
+			return Reference{}, fmt.Errorf("reference %q has no repository", ref)
+		}
+		return Reference{Registry: registry, Repository: repository, Digest: digest}, nil
+	}
+
+	repository, tag := rest, "latest"
+	if colon := strings.LastIndex(rest, ":"); colon >= 0 {
+		repository, tag = rest[:colon], rest[colon+1:]
+	}
+	if repository == "" {
+		return Reference{}, fmt.Errorf("reference %q has no repository", ref)
+	}
+	return Reference{Registry: registry, Repository: repository, Tag: tag}, nil
+}
@@ -0,0 +1,275 @@
+// Package pluginstore installs, verifies, and indexes external plugins
+// distributed as OCI artifacts, so the engine can run a third-party
+// plugin the same way it runs one built from source: as a command handed
+// to internal/supervisor. An install unpacks into
+// <dir>/<digest>/, named by the digest of its manifest so the on-disk
+// layout is immutable and content-addressable; a separate installed.json
+// index maps human-chosen aliases to those digests.
+package pluginstore
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/islo-labs/double-agent/pkg/sdk"
+)
+
+// InstalledPlugin is one entry in the store's index.
+type InstalledPlugin struct {
+	Alias    string             `json:"alias"`
+	Ref      string             `json:"ref"`
+	Digest   string             `json:"digest"`
+	Manifest sdk.PluginManifest `json:"manifest"`
+	Enabled  bool               `json:"enabled"`
+}
+
+// Store manages installed plugins under a root directory: content-
+// addressable unpacked artifacts plus an installed.json alias index.
+type Store struct {
+	dir string
+}
+
+// New returns a Store rooted at dir, creating it if necessary. dir is
+// typically a service's config.Config.PluginDir, so a plugin unpacked
+// here is already inside the directory the supervisor confines launched
+// commands to.
+func New(dir string) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("pluginstore: empty dir")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating plugin dir: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) indexPath() string {
+	return filepath.Join(s.dir, "installed.json")
+}
+
+func (s *Store) loadIndex() (map[string]InstalledPlugin, error) {
+	data, err := os.ReadFile(s.indexPath())
+	if os.IsNotExist(err) {
+		return map[string]InstalledPlugin{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading installed.json: %w", err)
+	}
+	var index map[string]InstalledPlugin
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("parsing installed.json: %w", err)
+	}
+	return index, nil
+}
+
+func (s *Store) saveIndex(index map[string]InstalledPlugin) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling installed.json: %w", err)
+	}
+	return os.WriteFile(s.indexPath(), data, 0o644)
+}
+
+// Install pulls the OCI artifact at ref, verifies every blob against its
+// claimed digest, unpacks it to <dir>/<manifest digest>/, and registers
+// it under alias (defaulting to the manifest's declared name).
+func (s *Store) Install(ref string, alias string) (InstalledPlugin, error) {
+	parsed, err := ParseReference(ref)
+	if err != nil {
+		return InstalledPlugin{}, err
+	}
+
+	client := newRegistryClient()
+	manifest, digest, err := client.pullManifest(parsed)
+	if err != nil {
+		return InstalledPlugin{}, err
+	}
+
+	configBlob, err := client.pullBlob(parsed, manifest.Config)
+	if err != nil {
+		return InstalledPlugin{}, fmt.Errorf("pulling plugin config: %w", err)
+	}
+	pluginManifest, err := decodeManifestConfig(configBlob)
+	if err != nil {
+		return InstalledPlugin{}, err
+	}
+	if len(manifest.Layers) == 0 {
+		return InstalledPlugin{}, fmt.Errorf("manifest for %s has no layers", ref)
+	}
+
+	installDir := filepath.Join(s.dir, digest)
+	if err := os.RemoveAll(installDir); err != nil {
+		return InstalledPlugin{}, fmt.Errorf("clearing install dir: %w", err)
+	}
+	if err := os.MkdirAll(installDir, 0o755); err != nil {
+		return InstalledPlugin{}, fmt.Errorf("creating install dir: %w", err)
+	}
+	for _, layer := range manifest.Layers {
+		blob, err := client.pullBlob(parsed, layer)
+		if err != nil {
+			return InstalledPlugin{}, fmt.Errorf("pulling layer %s: %w", layer.Digest, err)
+		}
+		if err := unpackLayer(blob, installDir); err != nil {
+			return InstalledPlugin{}, fmt.Errorf("unpacking layer %s: %w", layer.Digest, err)
+		}
+	}
+
+	if alias == "" {
+		alias = pluginManifest.Name
+	}
+	if alias == "" {
+		return InstalledPlugin{}, fmt.Errorf("plugin manifest for %s has no name; pass --alias", ref)
+	}
+
+	entry := InstalledPlugin{
+		Alias:    alias,
+		Ref:      parsed.String(),
+		Digest:   digest,
+		Manifest: pluginManifest,
+		Enabled:  true,
+	}
+
+	index, err := s.loadIndex()
+	if err != nil {
+		return InstalledPlugin{}, err
+	}
+	index[alias] = entry
+	if err := s.saveIndex(index); err != nil {
+		return InstalledPlugin{}, err
+	}
+	return entry, nil
+}
+
+// List returns every installed plugin, sorted by alias.
+func (s *Store) List() ([]InstalledPlugin, error) {
+	index, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]InstalledPlugin, 0, len(index))
+	for _, entry := range index {
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Alias < out[j].Alias })
+	return out, nil
+}
+
+// Remove drops alias from the index and deletes its unpacked files. It's
+// a no-op if alias isn't installed.
+func (s *Store) Remove(alias string) error {
+	index, err := s.loadIndex()
+	if err != nil {
+		return err
+	}
+	entry, ok := index[alias]
+	if !ok {
+		return nil
+	}
+	delete(index, alias)
+	if err := s.saveIndex(index); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(s.dir, entry.Digest))
+}
+
+// setEnabled flips alias's Enabled flag, failing if it isn't installed.
+func (s *Store) setEnabled(alias string, enabled bool) error {
+	index, err := s.loadIndex()
+	if err != nil {
+		return err
+	}
+	entry, ok := index[alias]
+	if !ok {
+		return fmt.Errorf("plugin %q is not installed", alias)
+	}
+	entry.Enabled = enabled
+	index[alias] = entry
+	return s.saveIndex(index)
+}
+
+// Enable marks alias as runnable by the engine.
+func (s *Store) Enable(alias string) error { return s.setEnabled(alias, true) }
+
+// Disable marks alias as not runnable; Resolve refuses a disabled alias.
+func (s *Store) Disable(alias string) error { return s.setEnabled(alias, false) }
+
+// Resolve returns the command to launch for alias, with its entrypoint's
+// first element made absolute under the plugin's install directory, or
+// ok=false if alias isn't installed and enabled.
+func (s *Store) Resolve(alias string) (command []string, ok bool, err error) {
+	index, err := s.loadIndex()
+	if err != nil {
+		return nil, false, err
+	}
+	entry, found := index[alias]
+	if !found || !entry.Enabled || len(entry.Manifest.Entrypoint) == 0 {
+		return nil, false, nil
+	}
+	installDir := filepath.Join(s.dir, entry.Digest)
+	command = append([]string{}, entry.Manifest.Entrypoint...)
+	if !filepath.IsAbs(command[0]) {
+		command[0] = filepath.Join(installDir, command[0])
+	}
+	return command, true, nil
+}
+
+// unpackLayer extracts a gzip-compressed tar layer into dir, rejecting
+// any entry whose path would escape dir.
+func unpackLayer(blob []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return fmt.Errorf("opening gzip stream: %w", err)
+	}
+	defer gz.Close()
+	return extractTar(gz, dir)
+}
+
+// extractTar extracts every entry of the tar stream r into dir,
+// rejecting any entry whose path would escape dir. It's the shared
+// extraction step behind both unpackLayer (gzip) and the https://
+// bundle path (zstd), which differ only in decompression.
+func extractTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading tar entry: %w", err)
+		}
+		target := filepath.Join(dir, hdr.Name)
+		rel, err := filepath.Rel(dir, target)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("layer entry %q escapes install dir", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(hdr.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			f.Close()
+		}
+	}
+}
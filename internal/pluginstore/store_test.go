@@ -0,0 +1,88 @@
+package pluginstore
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/islo-labs/double-agent/pkg/sdk"
+)
+
+// emptyGzippedTar returns a valid, empty gzip-compressed tar stream, a
+// stand-in layer Install can unpack without needing real plugin bytes.
+func emptyGzippedTar(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// TestStoreInstall_VerifiesDigestPin is the regression test for the
+// other half of the pullManifest bug: Install used to key its install
+// directory off digestOf(json.Marshal(manifest)) - a re-serialization
+// of the parsed struct - rather than the digest it was actually told to
+// pin, so the pin was never checked against anything real.
+func TestStoreInstall_VerifiesDigestPin(t *testing.T) {
+	pm := sdk.PluginManifest{Name: "acme", Version: "v1", Entrypoint: []string{"run"}}
+	configBlob, err := json.Marshal(pm)
+	require.NoError(t, err)
+	configDigest := digestOf(configBlob)
+
+	layerBlob := emptyGzippedTar(t)
+	layerDigest := digestOf(layerBlob)
+
+	manifest := ociManifest{
+		SchemaVersion: 2,
+		Config:        descriptor{MediaType: mediaTypeConfig, Digest: configDigest, Size: int64(len(configBlob))},
+		Layers:        []descriptor{{MediaType: mediaTypeLayer, Digest: layerDigest, Size: int64(len(layerBlob))}},
+	}
+	manifestBody, err := json.Marshal(manifest)
+	require.NoError(t, err)
+	manifestDigest := digestOf(manifestBody)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/manifests/"):
+			w.Write(manifestBody)
+		case strings.HasSuffix(r.URL.Path, configDigest):
+			w.Write(configBlob)
+		case strings.HasSuffix(r.URL.Path, layerDigest):
+			w.Write(layerBlob)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	// registryClient is always built by newRegistryClient() against
+	// http.DefaultClient, so swap it for the test TLS server's client
+	// rather than threading one through Store's public API.
+	prev := http.DefaultClient
+	http.DefaultClient = ts.Client()
+	defer func() { http.DefaultClient = prev }()
+
+	host := strings.TrimPrefix(ts.URL, "https://")
+	store, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	badDigest := strings.Repeat("0", len(strings.TrimPrefix(manifestDigest, "sha256:")))
+	_, err = store.Install(fmt.Sprintf("%s/acme/fake@sha256:%s", host, badDigest), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "digest mismatch")
+
+	installed, err := store.Install(fmt.Sprintf("%s/acme/fake@%s", host, manifestDigest), "")
+	require.NoError(t, err)
+	assert.Equal(t, manifestDigest, installed.Digest)
+}
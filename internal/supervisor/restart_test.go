@@ -0,0 +1,28 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJitter_StaysWithinTwentyPercent(t *testing.T) {
+	d := 10 * time.Second
+	for i := 0; i < 100; i++ {
+		got := jitter(d)
+		lo := time.Duration(float64(d) * 0.8)
+		hi := time.Duration(float64(d) * 1.2)
+		assert.True(t, got >= lo && got <= hi, "jitter(%s) = %s, want within [%s, %s]", d, got, lo, hi)
+	}
+}
+
+func TestHealth_ReportsStateAndRestarts(t *testing.T) {
+	s := &Supervisor{state: StateFailed, restarts: 3}
+	assert.JSONEq(t, `{"state":"failed","restarts":3}`, string(s.Health()))
+}
+
+func TestHealth_RunningWithNoRestarts(t *testing.T) {
+	s := &Supervisor{state: StateRunning, restarts: 0}
+	assert.JSONEq(t, `{"state":"running","restarts":0}`, string(s.Health()))
+}
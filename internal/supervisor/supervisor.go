@@ -0,0 +1,436 @@
+// Package supervisor launches and manages external plugin subprocesses
+// for internal/engine. It confines a launched binary to a configured
+// plugin directory, verifies protocol compatibility with an info
+// handshake, pings health on an interval, restarts crashed or
+// unresponsive plugins with exponential backoff, and forwards captured
+// stderr to log.Printf tagged with the plugin's name. Supervisor itself
+// implements sdk.Plugin (and sdk.Notifier/sdk.Snapshotter) so the engine
+// can treat a supervised plugin exactly like a built-in one.
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/islo-labs/double-agent/pkg/sdk"
+)
+
+// State describes where a supervised plugin sits in its restart
+// lifecycle, exposed via Supervisor.State and the engine's GET
+// /_/health endpoint so tests and ops tooling can observe lifecycle
+// transitions without guessing from logs.
+type State string
+
+const (
+	StateStarting   State = "starting"
+	StateRunning    State = "running"
+	StateRestarting State = "restarting"
+	StateFailed     State = "failed" // exceeded MaxRestarts; the circuit breaker has tripped and won't retry further
+)
+
+// Config controls how a Supervisor launches and monitors a plugin.
+type Config struct {
+	// Name tags captured stderr and health/restart log lines.
+	Name string
+	// Command is the subprocess argv. Command[0] is resolved relative
+	// to Dir if it isn't already absolute, and launch fails if it
+	// would resolve outside Dir.
+	Command []string
+	// Dir confines where Command[0] may resolve to. Empty disables
+	// confinement and Command is used as-is.
+	Dir string
+
+	// HealthInterval is how often a live info ping checks that the
+	// subprocess is still responsive. Defaults to 10s.
+	HealthInterval time.Duration
+	// UnhealthyAfter is the number of consecutive failed health pings
+	// before the plugin is restarted. Defaults to 3.
+	UnhealthyAfter int
+	// MaxRestarts caps how many times a crashed or unhealthy plugin is
+	// relaunched. Zero means unlimited.
+	MaxRestarts int
+	// RestartBackoff is the delay before the first restart attempt;
+	// each subsequent attempt doubles it, capped at MaxRestartBackoff.
+	// Defaults to 1s.
+	RestartBackoff time.Duration
+	// MaxRestartBackoff caps the exponential backoff delay. Defaults
+	// to 30s.
+	MaxRestartBackoff time.Duration
+	// ShutdownGrace is how long Stop waits for the subprocess to exit
+	// after stdin closes before sending SIGKILL. Defaults to 5s.
+	ShutdownGrace time.Duration
+}
+
+func (c *Config) setDefaults() {
+	if c.HealthInterval <= 0 {
+		c.HealthInterval = 10 * time.Second
+	}
+	if c.UnhealthyAfter <= 0 {
+		c.UnhealthyAfter = 3
+	}
+	if c.RestartBackoff <= 0 {
+		c.RestartBackoff = time.Second
+	}
+	if c.MaxRestartBackoff <= 0 {
+		c.MaxRestartBackoff = 30 * time.Second
+	}
+	if c.ShutdownGrace <= 0 {
+		c.ShutdownGrace = 5 * time.Second
+	}
+}
+
+// Supervisor launches and supervises one external plugin subprocess. It
+// implements sdk.Plugin by delegating to whichever *sdk.ExternalPlugin
+// is currently live, swapping it out transparently across restarts.
+type Supervisor struct {
+	cfg Config
+
+	mu       sync.RWMutex
+	current  *sdk.ExternalPlugin
+	env      map[string]string
+	notify   func(sdk.Notification)
+	restarts int
+	state    State
+	stopped  bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Start resolves cfg.Command, launches the subprocess, performs the
+// initial info handshake, and starts the background health/restart
+// loop.
+func Start(cfg Config) (*Supervisor, error) {
+	cfg.setDefaults()
+	s := &Supervisor{cfg: cfg, state: StateStarting, stop: make(chan struct{}), done: make(chan struct{})}
+	ext, err := s.launch()
+	if err != nil {
+		return nil, err
+	}
+	s.current = ext
+	s.state = StateRunning
+	go s.healthLoop()
+	return s, nil
+}
+
+// State reports the supervised plugin's current lifecycle state.
+func (s *Supervisor) State() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// launch resolves the confined command, starts the subprocess, and
+// performs the info handshake to verify it speaks the protocol before
+// handing it back.
+func (s *Supervisor) launch() (*sdk.ExternalPlugin, error) {
+	command, err := resolveCommand(s.cfg.Dir, s.cfg.Command)
+	if err != nil {
+		return nil, err
+	}
+	ext, err := sdk.StartExternalPluginWithStderr(command, stderrLogger(s.cfg.Name))
+	if err != nil {
+		return nil, fmt.Errorf("launching plugin %s: %w", s.cfg.Name, err)
+	}
+	if err := ext.Ping(context.Background()); err != nil {
+		ext.Stop()
+		return nil, fmt.Errorf("plugin %s failed info handshake: %w", s.cfg.Name, err)
+	}
+	return ext, nil
+}
+
+// healthLoop pings the current subprocess on an interval, restarting it
+// with exponential backoff once it's failed UnhealthyAfter pings in a
+// row, and also restarts it immediately on an unexpected exit observed
+// via cur.Done(), without waiting for the next scheduled ping.
+func (s *Supervisor) healthLoop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.cfg.HealthInterval)
+	defer ticker.Stop()
+
+	failures := 0
+	backoff := s.cfg.RestartBackoff
+
+	for {
+		s.mu.RLock()
+		cur := s.current
+		s.mu.RUnlock()
+
+		select {
+		case <-s.stop:
+			return
+		case <-cur.Done():
+			log.Printf("supervisor %s: subprocess exited unexpectedly", s.cfg.Name)
+			var ok bool
+			backoff, ok = s.restart(cur, backoff)
+			if !ok {
+				return
+			}
+			failures = 0
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), s.cfg.HealthInterval)
+			err := cur.Ping(ctx)
+			cancel()
+			if err == nil {
+				failures = 0
+				backoff = s.cfg.RestartBackoff
+				continue
+			}
+
+			failures++
+			log.Printf("supervisor %s: health ping failed (%d/%d): %v", s.cfg.Name, failures, s.cfg.UnhealthyAfter, err)
+			if failures < s.cfg.UnhealthyAfter {
+				continue
+			}
+			failures = 0
+
+			var ok bool
+			backoff, ok = s.restart(cur, backoff)
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// restart retires cur, waits out a jittered exponential backoff, and
+// launches and adopts a replacement, tripping the circuit breaker
+// (StateFailed) instead if MaxRestarts has been exceeded. It returns
+// the next backoff to use and whether the loop should keep running.
+func (s *Supervisor) restart(cur *sdk.ExternalPlugin, backoff time.Duration) (time.Duration, bool) {
+	s.mu.Lock()
+	s.state = StateRestarting
+	s.restarts++
+	restarts := s.restarts
+	s.mu.Unlock()
+
+	if s.cfg.MaxRestarts > 0 && restarts > s.cfg.MaxRestarts {
+		log.Printf("supervisor %s: exceeded max restarts (%d), giving up", s.cfg.Name, s.cfg.MaxRestarts)
+		s.mu.Lock()
+		s.state = StateFailed
+		s.mu.Unlock()
+		return backoff, false
+	}
+
+	wait := jitter(backoff)
+	log.Printf("supervisor %s: restarting after %s backoff", s.cfg.Name, wait)
+	select {
+	case <-time.After(wait):
+	case <-s.stop:
+		return backoff, false
+	}
+	backoff *= 2
+	if backoff > s.cfg.MaxRestartBackoff {
+		backoff = s.cfg.MaxRestartBackoff
+	}
+
+	cur.StopGraceful(s.cfg.ShutdownGrace)
+	next, err := s.launch()
+	if err != nil {
+		log.Printf("supervisor %s: restart failed: %v", s.cfg.Name, err)
+		return backoff, true
+	}
+	s.adopt(next)
+	return backoff, true
+}
+
+// jitter returns d adjusted by up to ±20%, so that many supervised
+// plugins crashing around the same time (e.g. a shared dependency blip)
+// don't all retry in lockstep.
+func jitter(d time.Duration) time.Duration {
+	spread := float64(d) * 0.2
+	return d + time.Duration(spread*(rand.Float64()*2-1))
+}
+
+// adopt installs next as the current subprocess, reapplying whatever
+// Configure/SetNotifyFunc calls the engine made against the previous
+// one so a restart is invisible to callers.
+func (s *Supervisor) adopt(next *sdk.ExternalPlugin) {
+	s.mu.Lock()
+	env := s.env
+	notify := s.notify
+	s.current = next
+	s.state = StateRunning
+	s.mu.Unlock()
+
+	if env != nil {
+		if err := next.Configure(env); err != nil {
+			log.Printf("supervisor %s: reconfigure after restart failed: %v", s.cfg.Name, err)
+		}
+	}
+	if notify != nil {
+		next.SetNotifyFunc(notify)
+	}
+}
+
+// Info implements sdk.Plugin.
+func (s *Supervisor) Info() sdk.PluginInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current.Info()
+}
+
+// Manifest implements sdk.Plugin.
+func (s *Supervisor) Manifest() sdk.PluginManifest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current.Manifest()
+}
+
+// Configure implements sdk.Plugin. The env is remembered so a plugin
+// relaunched after a crash is reconfigured the same way.
+func (s *Supervisor) Configure(env map[string]string) error {
+	s.mu.Lock()
+	s.env = env
+	cur := s.current
+	s.mu.Unlock()
+	return cur.Configure(env)
+}
+
+// ServeHTTP implements sdk.Plugin. While the subprocess is being
+// restarted (or has given up after MaxRestarts) it fails fast with 503
+// and a Retry-After hint rather than forwarding to a plugin that isn't
+// there to answer, so a caller's request doesn't hang across a restart.
+func (s *Supervisor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	cur := s.current
+	state := s.state
+	s.mu.RUnlock()
+
+	if state != StateRunning {
+		w.Header().Set("Retry-After", "1")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, `{"error":"plugin %s is %s"}`, s.cfg.Name, state)
+		return
+	}
+	cur.ServeHTTP(w, r)
+}
+
+// Health returns a JSON document describing the supervised plugin's
+// current state and restart count, for the engine's GET /_/health
+// endpoint.
+func (s *Supervisor) Health() []byte {
+	s.mu.RLock()
+	state, restarts := s.state, s.restarts
+	s.mu.RUnlock()
+	return []byte(`{"state":"` + string(state) + `","restarts":` + strconv.Itoa(restarts) + `}`)
+}
+
+// Reset implements sdk.Plugin.
+func (s *Supervisor) Reset() error {
+	s.mu.RLock()
+	cur := s.current
+	s.mu.RUnlock()
+	return cur.Reset()
+}
+
+// SetNotifyFunc implements sdk.Notifier. The callback is remembered so
+// a plugin relaunched after a crash keeps pushing notifications.
+func (s *Supervisor) SetNotifyFunc(fn func(sdk.Notification)) {
+	s.mu.Lock()
+	s.notify = fn
+	cur := s.current
+	s.mu.Unlock()
+	cur.SetNotifyFunc(fn)
+}
+
+// Snapshot implements sdk.Snapshotter.
+func (s *Supervisor) Snapshot() (json.RawMessage, error) {
+	s.mu.RLock()
+	cur := s.current
+	s.mu.RUnlock()
+	return cur.Snapshot()
+}
+
+// Restore implements sdk.Snapshotter.
+func (s *Supervisor) Restore(data json.RawMessage) error {
+	s.mu.RLock()
+	cur := s.current
+	s.mu.RUnlock()
+	return cur.Restore(data)
+}
+
+// Stop gracefully shuts down the supervised subprocess and stops the
+// health/restart loop. Safe to call once; later calls are no-ops.
+func (s *Supervisor) Stop() error {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return nil
+	}
+	s.stopped = true
+	cur := s.current
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.done
+	return cur.StopGraceful(s.cfg.ShutdownGrace)
+}
+
+// resolveCommand resolves command[0] against dir (if set) and rejects
+// any result that would escape dir, so a config-supplied command can't
+// be used to launch a binary outside the configured plugin directory.
+func resolveCommand(dir string, command []string) ([]string, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	if dir == "" {
+		return command, nil
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving plugin dir: %w", err)
+	}
+	bin := command[0]
+	if !filepath.IsAbs(bin) {
+		bin = filepath.Join(absDir, bin)
+	}
+	absBin, err := filepath.Abs(bin)
+	if err != nil {
+		return nil, fmt.Errorf("resolving command: %w", err)
+	}
+	rel, err := filepath.Rel(absDir, absBin)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("command %q escapes plugin directory %q", command[0], dir)
+	}
+
+	resolved := make([]string, len(command))
+	resolved[0] = absBin
+	copy(resolved[1:], command[1:])
+	return resolved, nil
+}
+
+// stderrLogger returns an io.Writer that forwards each line written to
+// it to log.Printf, tagged with the plugin's name.
+func stderrLogger(name string) io.Writer {
+	return &linePrefixWriter{prefix: fmt.Sprintf("plugin %s: ", name)}
+}
+
+// linePrefixWriter logs each write tagged with prefix. Plugin stderr
+// isn't guaranteed to arrive one line per Write, so this covers the
+// common case of line-buffered output without reassembling a stream
+// split mid-line.
+type linePrefixWriter struct {
+	prefix string
+}
+
+func (w *linePrefixWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line != "" {
+			log.Printf("%s%s", w.prefix, line)
+		}
+	}
+	return len(p), nil
+}
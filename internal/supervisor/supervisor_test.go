@@ -0,0 +1,72 @@
+package supervisor
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveCommand_ResolvesRelativeToDir(t *testing.T) {
+	dir := t.TempDir()
+	command, err := resolveCommand(dir, []string{"plugin", "--flag"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "plugin"), command[0])
+	assert.Equal(t, []string{"--flag"}, command[1:])
+}
+
+func TestResolveCommand_RejectsEscape(t *testing.T) {
+	dir := t.TempDir()
+	_, err := resolveCommand(dir, []string{"../../etc/passwd"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "escapes plugin directory")
+}
+
+func TestResolveCommand_EmptyDirDisablesConfinement(t *testing.T) {
+	command, err := resolveCommand("", []string{"/usr/bin/plugin"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/usr/bin/plugin"}, command)
+}
+
+func TestResolveCommand_RejectsEmptyCommand(t *testing.T) {
+	_, err := resolveCommand("", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_SetDefaults(t *testing.T) {
+	c := Config{}
+	c.setDefaults()
+	assert.Equal(t, 10*time.Second, c.HealthInterval)
+	assert.Equal(t, 3, c.UnhealthyAfter)
+	assert.Equal(t, time.Second, c.RestartBackoff)
+	assert.Equal(t, 30*time.Second, c.MaxRestartBackoff)
+	assert.Equal(t, 5*time.Second, c.ShutdownGrace)
+}
+
+func TestConfig_SetDefaults_PreservesExplicitValues(t *testing.T) {
+	c := Config{HealthInterval: time.Minute, UnhealthyAfter: 1}
+	c.setDefaults()
+	assert.Equal(t, time.Minute, c.HealthInterval)
+	assert.Equal(t, 1, c.UnhealthyAfter)
+}
+
+func TestLinePrefixWriter_SplitsLines(t *testing.T) {
+	var buf bytes.Buffer
+	prevOut, prevFlags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() { log.SetOutput(prevOut); log.SetFlags(prevFlags) })
+
+	w := &linePrefixWriter{prefix: "plugin x: "}
+	n, err := w.Write([]byte("first\nsecond\n\n"))
+	require.NoError(t, err)
+	assert.Equal(t, len("first\nsecond\n\n"), n)
+
+	logged := strings.TrimRight(buf.String(), "\n")
+	assert.Equal(t, "plugin x: first\nplugin x: second", logged)
+}
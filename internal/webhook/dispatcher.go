@@ -0,0 +1,60 @@
+// Package webhook delivers outbound webhook notifications emitted by
+// plugins to the URLs configured for them.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/islo-labs/double-agent/pkg/sdk"
+)
+
+// Dispatcher watches for "webhook" notifications from a plugin and POSTs
+// them to the URL carried in the notification params. Deliveries happen
+// on their own goroutine so a slow or unreachable target never blocks the
+// plugin's request handling.
+type Dispatcher struct {
+	client *http.Client
+}
+
+// New creates a Dispatcher using http.DefaultClient.
+func New() *Dispatcher {
+	return &Dispatcher{client: http.DefaultClient}
+}
+
+// Handle is registered as a plugin's notify callback via
+// sdk.Notifier.SetNotifyFunc. It ignores any notification whose method
+// isn't "webhook".
+func (d *Dispatcher) Handle(n sdk.Notification) {
+	if n.Method != "webhook" {
+		return
+	}
+	var params sdk.WebhookParams
+	if err := json.Unmarshal(n.Params, &params); err != nil {
+		log.Printf("webhook: invalid params: %v", err)
+		return
+	}
+	go d.deliver(params)
+}
+
+func (d *Dispatcher) deliver(params sdk.WebhookParams) {
+	req, err := http.NewRequest(http.MethodPost, params.URL, bytes.NewReader([]byte(params.Body)))
+	if err != nil {
+		log.Printf("webhook: building request to %s: %v", params.URL, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range params.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("X-DoubleAgent-Event", params.Event)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		log.Printf("webhook: delivering %s to %s: %v", params.Event, params.URL, err)
+		return
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,101 @@
+// Package auth provides pluggable credential verification for plugin HTTP
+// handlers, modeled on the token/login-password/OAuth credential shapes
+// real vendor APIs use. Plugins that want realistic 401/403 behavior wrap
+// their ServeHTTP in Middleware with whichever AuthScheme matches the
+// vendor they fake.
+package auth
+
+import (
+	"context"
+	"net/http"
+)
+
+// CredentialKind identifies what a Credential represents. It's informational
+// only — schemes match on Secret/Subject regardless of Kind — and
+// records whether a credential is a token, a login/password pair, or a
+// token minted by an OAuth flow.
+type CredentialKind string
+
+const (
+	KindToken         CredentialKind = "token"
+	KindLoginPassword CredentialKind = "login_password"
+	KindOAuthApp      CredentialKind = "oauth_app"
+)
+
+// Credential is one identity an AuthScheme will accept.
+type Credential struct {
+	Subject string         `json:"subject"`          // principal name, e.g. a username or token owner
+	Secret  string         `json:"secret"`           // the token, password, or cookie value to match
+	Scopes  []string       `json:"scopes,omitempty"` // scopes/permissions granted to this credential
+	Kind    CredentialKind `json:"kind,omitempty"`
+}
+
+// Principal identifies the caller an AuthScheme resolved a request to.
+type Principal struct {
+	Subject string
+	Scopes  []string
+}
+
+// HasScope reports whether the principal was granted scope.
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Error is returned by an AuthScheme when a request fails authentication.
+// Status and Body let each scheme reproduce its vendor's exact error shape.
+type Error struct {
+	Status int
+	Body   string // raw JSON body to write verbatim
+}
+
+func (e *Error) Error() string { return e.Body }
+
+// AuthScheme validates an incoming request and returns the Principal it
+// authenticates as, or an *Error describing how the host should fail it.
+type AuthScheme interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+type principalKey struct{}
+
+// FromContext returns the Principal Middleware attached to ctx, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Middleware wraps next so requests must satisfy scheme before reaching
+// it. On failure it writes the scheme's vendor-shaped error body instead
+// of calling next; on success the resolved Principal is attached to the
+// request context for handlers to read via FromContext.
+func Middleware(scheme AuthScheme, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := scheme.Authenticate(r)
+		if err != nil {
+			authErr, ok := err.(*Error)
+			if !ok {
+				authErr = &Error{Status: http.StatusUnauthorized, Body: `{"message":"` + err.Error() + `"}`}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(authErr.Status)
+			w.Write([]byte(authErr.Body))
+			return
+		}
+		ctx := context.WithValue(r.Context(), principalKey{}, principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireScope reports whether the request's context Principal was
+// granted scope. Plugins call this from route handlers, after Middleware
+// has run, to enforce per-route scope checks (e.g. creating a repo
+// requires "repo").
+func RequireScope(r *http.Request, scope string) bool {
+	p, ok := FromContext(r.Context())
+	return ok && p.HasScope(scope)
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrincipal_HasScope(t *testing.T) {
+	p := Principal{Subject: "alice", Scopes: []string{"repo", "read:org"}}
+	assert.True(t, p.HasScope("repo"))
+	assert.False(t, p.HasScope("admin:org"))
+}
+
+func TestMiddleware_AuthenticatesAndAttachesPrincipal(t *testing.T) {
+	store := NewCredentialStore([]Credential{{Subject: "alice", Secret: "tok", Scopes: []string{"repo"}}})
+	var gotPrincipal Principal
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPrincipal, _ = FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	h := Middleware(BearerToken{Credentials: store}, next)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer tok")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice", gotPrincipal.Subject)
+	assert.True(t, gotPrincipal.HasScope("repo"))
+}
+
+func TestMiddleware_RejectsUnauthenticated(t *testing.T) {
+	store := NewCredentialStore([]Credential{{Subject: "alice", Secret: "tok"}})
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	h := Middleware(BearerToken{Credentials: store}, next)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, called, "next must not run on failed authentication")
+}
+
+func TestFromContext_AbsentWhenNotSet(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestRequireScope(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), principalKey{}, Principal{Scopes: []string{"repo"}}))
+	assert.True(t, RequireScope(r, "repo"))
+	assert.False(t, RequireScope(r, "admin:org"))
+
+	bare := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.False(t, RequireScope(bare, "repo"))
+}
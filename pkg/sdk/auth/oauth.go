@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// OAuthCodeFlow implements a simplified OAuth2 authorization-code grant
+// against a CredentialStore: Authorize mints a single-use code bound to
+// the requested scope (codes do not expire until redeemed), AccessToken
+// exchanges that code for a token
+// minted straight into Store. It doesn't validate client_id/client_secret
+// against a registered app list — any caller that completes the
+// redirect round trip gets a token, matching how these fakes trust
+// whatever the test harness sends them.
+type OAuthCodeFlow struct {
+	Store *CredentialStore
+	// TokenPrefix is prepended to every minted token, e.g. "gho_" for a
+	// GitHub-shaped OAuth app token.
+	TokenPrefix string
+
+	mu    sync.Mutex
+	codes map[string][]string // code -> scopes
+}
+
+// Authorize mints a code for the requested scope and redirects to
+// redirect_uri with it attached, the way a user clicking "Authorize"
+// lands back in the calling app. Without a redirect_uri it returns the
+// code as JSON instead, for callers driving the flow directly.
+func (f *OAuthCodeFlow) Authorize(w http.ResponseWriter, r *http.Request) {
+	redirectURI := r.URL.Query().Get("redirect_uri")
+	scope := splitScope(r.URL.Query().Get("scope"))
+	state := r.URL.Query().Get("state")
+
+	code := f.mintCode(scope)
+
+	if redirectURI == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"code": code, "state": state})
+		return
+	}
+	dest, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, `{"message":"invalid redirect_uri"}`, http.StatusBadRequest)
+		return
+	}
+	q := dest.Query()
+	q.Set("code", code)
+	if state != "" {
+		q.Set("state", state)
+	}
+	dest.RawQuery = q.Encode()
+	http.Redirect(w, r, dest.String(), http.StatusFound)
+}
+
+// AccessToken exchanges a code minted by Authorize for a freshly minted
+// credential registered in Store, returned in the vendor's usual
+// form-encoded shape unless the caller asks for JSON via Accept.
+func (f *OAuthCodeFlow) AccessToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	scope, ok := f.redeemCode(r.FormValue("code"))
+	if !ok {
+		http.Error(w, `{"error":"bad_verification_code"}`, http.StatusBadRequest)
+		return
+	}
+
+	token := f.TokenPrefix + randomHex(20)
+	f.Store.Add(Credential{Subject: token, Secret: token, Scopes: scope, Kind: KindOAuthApp})
+
+	if strings.Contains(r.Header.Get("Accept"), "json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": token,
+			"scope":        strings.Join(scope, ","),
+			"token_type":   "bearer",
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+	fmt.Fprintf(w, "access_token=%s&scope=%s&token_type=bearer", token, url.QueryEscape(strings.Join(scope, ",")))
+}
+
+func (f *OAuthCodeFlow) mintCode(scope []string) string {
+	code := randomHex(16)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.codes == nil {
+		f.codes = make(map[string][]string)
+	}
+	f.codes[code] = scope
+	return code
+}
+
+// redeemCode returns the scope a code was minted with and consumes it;
+// a code can only be exchanged once.
+func (f *OAuthCodeFlow) redeemCode(code string) ([]string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	scope, ok := f.codes[code]
+	if ok {
+		delete(f.codes, code)
+	}
+	return scope, ok
+}
+
+func splitScope(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	return strings.Fields(strings.ReplaceAll(raw, ",", " "))
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthCodeFlow_AuthorizeWithoutRedirectReturnsCodeJSON(t *testing.T) {
+	f := &OAuthCodeFlow{Store: NewCredentialStore(nil)}
+
+	r := httptest.NewRequest(http.MethodGet, "/authorize?scope=repo+read:org&state=xyz", nil)
+	w := httptest.NewRecorder()
+	f.Authorize(w, r)
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"state":"xyz"`)
+}
+
+func TestOAuthCodeFlow_AuthorizeWithRedirectURI(t *testing.T) {
+	f := &OAuthCodeFlow{Store: NewCredentialStore(nil)}
+
+	r := httptest.NewRequest(http.MethodGet, "/authorize?redirect_uri=https://app.example/cb&state=xyz", nil)
+	w := httptest.NewRecorder()
+	f.Authorize(w, r)
+
+	require.Equal(t, http.StatusFound, w.Code)
+	loc, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, loc.Query().Get("code"))
+	assert.Equal(t, "xyz", loc.Query().Get("state"))
+}
+
+func TestOAuthCodeFlow_AccessTokenExchangesCodeOnce(t *testing.T) {
+	f := &OAuthCodeFlow{Store: NewCredentialStore(nil), TokenPrefix: "gho_"}
+	code := f.mintCode([]string{"repo"})
+
+	form := url.Values{"code": {code}}
+	r := httptest.NewRequest(http.MethodPost, "/token", nil)
+	r.PostForm = form
+	w := httptest.NewRecorder()
+	f.AccessToken(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "access_token=gho_")
+
+	cred, ok := f.Store.Find(extractToken(t, body))
+	require.True(t, ok)
+	assert.Equal(t, []string{"repo"}, cred.Scopes)
+	assert.Equal(t, KindOAuthApp, cred.Kind)
+
+	// A code can only be redeemed once.
+	r2 := httptest.NewRequest(http.MethodPost, "/token", nil)
+	r2.PostForm = form
+	w2 := httptest.NewRecorder()
+	f.AccessToken(w2, r2)
+	assert.Equal(t, http.StatusBadRequest, w2.Code)
+}
+
+func TestOAuthCodeFlow_AccessTokenRejectsUnknownCode(t *testing.T) {
+	f := &OAuthCodeFlow{Store: NewCredentialStore(nil)}
+
+	r := httptest.NewRequest(http.MethodPost, "/token", nil)
+	r.PostForm = url.Values{"code": {"bogus"}}
+	w := httptest.NewRecorder()
+	f.AccessToken(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "bad_verification_code")
+}
+
+func TestSplitScope(t *testing.T) {
+	assert.Equal(t, []string{"repo", "read:org"}, splitScope("repo,read:org"))
+	assert.Nil(t, splitScope(""))
+}
+
+// extractToken pulls the access_token value out of the form-encoded
+// AccessToken response body.
+func extractToken(t *testing.T, body string) string {
+	t.Helper()
+	values, err := url.ParseQuery(body)
+	require.NoError(t, err)
+	return values.Get("access_token")
+}
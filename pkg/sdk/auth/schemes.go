@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// BearerToken authenticates "Authorization: Bearer <token>" requests.
+type BearerToken struct {
+	Credentials *CredentialStore
+}
+
+// Authenticate implements AuthScheme.
+func (b BearerToken) Authenticate(r *http.Request) (Principal, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return Principal{}, &Error{Status: http.StatusUnauthorized, Body: `{"message":"requires authentication"}`}
+	}
+	cred, ok := b.Credentials.Find(token)
+	if !ok {
+		return Principal{}, &Error{Status: http.StatusUnauthorized, Body: `{"message":"bad credentials"}`}
+	}
+	return Principal{Subject: cred.Subject, Scopes: cred.Scopes}, nil
+}
+
+// BasicAuth authenticates HTTP Basic "username:password" requests.
+type BasicAuth struct {
+	Credentials *CredentialStore // Subject is the username, Secret is the password
+}
+
+// Authenticate implements AuthScheme.
+func (b BasicAuth) Authenticate(r *http.Request) (Principal, error) {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return Principal{}, &Error{Status: http.StatusUnauthorized, Body: `{"message":"requires authentication"}`}
+	}
+	cred, ok := b.Credentials.FindBasic(user, pass)
+	if !ok {
+		return Principal{}, &Error{Status: http.StatusUnauthorized, Body: `{"message":"bad credentials"}`}
+	}
+	return Principal{Subject: cred.Subject, Scopes: cred.Scopes}, nil
+}
+
+// JiraSessionCookie authenticates requests carrying a Jira-style session
+// cookie whose value matches a credential's Secret in Credentials.
+type JiraSessionCookie struct {
+	Credentials *CredentialStore // Secret is the cookie value
+	CookieName  string           // defaults to "JSESSIONID"
+}
+
+// Authenticate implements AuthScheme.
+func (j JiraSessionCookie) Authenticate(r *http.Request) (Principal, error) {
+	name := j.CookieName
+	if name == "" {
+		name = "JSESSIONID"
+	}
+	cookie, err := r.Cookie(name)
+	if err != nil || cookie.Value == "" {
+		return Principal{}, &Error{Status: http.StatusUnauthorized, Body: `{"errorMessages":["You are not authenticated"]}`}
+	}
+	cred, ok := j.Credentials.Find(cookie.Value)
+	if !ok {
+		return Principal{}, &Error{Status: http.StatusUnauthorized, Body: `{"errorMessages":["You are not authenticated"]}`}
+	}
+	return Principal{Subject: cred.Subject, Scopes: cred.Scopes}, nil
+}
+
+// GitHubPAT authenticates GitHub-style personal access tokens, accepted
+// either as "Authorization: Bearer <pat>" or the legacy "token <pat>"
+// scheme, and fails in GitHub's own error shape.
+type GitHubPAT struct {
+	Credentials *CredentialStore
+}
+
+// Authenticate implements AuthScheme.
+func (g GitHubPAT) Authenticate(r *http.Request) (Principal, error) {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		token, ok = strings.CutPrefix(header, "token ")
+	}
+	if !ok || token == "" {
+		return Principal{}, &Error{
+			Status: http.StatusUnauthorized,
+			Body:   `{"message":"Requires authentication","documentation_url":"https://docs.github.com/rest"}`,
+		}
+	}
+	cred, ok := g.Credentials.Find(token)
+	if !ok {
+		return Principal{}, &Error{
+			Status: http.StatusUnauthorized,
+			Body:   `{"message":"Bad credentials","documentation_url":"https://docs.github.com/rest"}`,
+		}
+	}
+	return Principal{Subject: cred.Subject, Scopes: cred.Scopes}, nil
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"sync"
+)
+
+// CredentialStore holds the credentials an AuthScheme accepts. It's
+// seeded from HCL at startup (internal/engine.buildAuthScheme) and
+// mutable afterward, via POST /_/credentials or a completed OAuth code
+// exchange, so a test can provision a new identity without a config
+// reload.
+type CredentialStore struct {
+	mu    sync.RWMutex
+	seed  []Credential
+	creds []Credential
+}
+
+// NewCredentialStore returns a store seeded with creds. Reset restores
+// the store to exactly this seed, discarding anything added since.
+func NewCredentialStore(seed []Credential) *CredentialStore {
+	s := &CredentialStore{seed: append([]Credential{}, seed...)}
+	s.Reset()
+	return s
+}
+
+// Add registers cred, replacing any existing credential with the same
+// Subject.
+func (s *CredentialStore) Add(cred Credential) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, c := range s.creds {
+		if c.Subject == cred.Subject {
+			s.creds[i] = cred
+			return
+		}
+	}
+	s.creds = append(s.creds, cred)
+}
+
+// Remove drops the credential with the given subject, if any.
+func (s *CredentialStore) Remove(subject string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.creds[:0]
+	for _, c := range s.creds {
+		if c.Subject != subject {
+			out = append(out, c)
+		}
+	}
+	s.creds = out
+}
+
+// List returns a snapshot of every registered credential.
+func (s *CredentialStore) List() []Credential {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Credential{}, s.creds...)
+}
+
+// Find returns the credential whose Secret matches secret, for token,
+// cookie, and bearer-style schemes. The comparison is constant-time,
+// since a bearer token can guard real lifecycle actions (e.g. the
+// admin control plane's own token) and a timing side-channel would
+// otherwise leak it one byte at a time.
+func (s *CredentialStore) Find(secret string) (Credential, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, c := range s.creds {
+		if secretsEqual(c.Secret, secret) {
+			return c, true
+		}
+	}
+	return Credential{}, false
+}
+
+// FindBasic returns the credential matching a username/password pair,
+// for BasicAuth, where Subject is the username. The password
+// comparison is constant-time for the same reason as Find.
+func (s *CredentialStore) FindBasic(user, pass string) (Credential, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, c := range s.creds {
+		if c.Subject == user && secretsEqual(c.Secret, pass) {
+			return c, true
+		}
+	}
+	return Credential{}, false
+}
+
+// secretsEqual reports whether a and b match, in time independent of
+// where they first differ.
+func secretsEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// Reset restores the store to its original seed, discarding any
+// credential added or minted since.
+func (s *CredentialStore) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.creds = append([]Credential{}, s.seed...)
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCredentialStore_FindMatchesSecret is the regression test for a
+// review comment: Find and FindBasic compared secrets with a plain
+// == (here replaced by secretsEqual's constant-time compare), so this
+// confirms the constant-time swap didn't change which credentials
+// actually match.
+func TestCredentialStore_FindMatchesSecret(t *testing.T) {
+	s := NewCredentialStore([]Credential{{Subject: "admin", Secret: "hunter2"}})
+
+	cred, ok := s.Find("hunter2")
+	assert.True(t, ok)
+	assert.Equal(t, "admin", cred.Subject)
+
+	_, ok = s.Find("wrong")
+	assert.False(t, ok)
+
+	_, ok = s.Find("hunter2x")
+	assert.False(t, ok, "a secret differing only by a trailing byte must not match")
+
+	cred, ok = s.FindBasic("admin", "hunter2")
+	assert.True(t, ok)
+	assert.Equal(t, "admin", cred.Subject)
+
+	_, ok = s.FindBasic("admin", "wrong")
+	assert.False(t, ok)
+}
+
+func TestCredentialStore_AddReplacesExistingSubject(t *testing.T) {
+	s := NewCredentialStore([]Credential{{Subject: "alice", Secret: "old"}})
+	s.Add(Credential{Subject: "alice", Secret: "new"})
+
+	_, ok := s.Find("old")
+	assert.False(t, ok)
+	cred, ok := s.Find("new")
+	require.True(t, ok)
+	assert.Equal(t, "alice", cred.Subject)
+	assert.Len(t, s.List(), 1)
+}
+
+func TestCredentialStore_AddAppendsNewSubject(t *testing.T) {
+	s := NewCredentialStore([]Credential{{Subject: "alice", Secret: "a"}})
+	s.Add(Credential{Subject: "bob", Secret: "b"})
+	assert.Len(t, s.List(), 2)
+}
+
+func TestCredentialStore_Remove(t *testing.T) {
+	s := NewCredentialStore([]Credential{{Subject: "alice", Secret: "a"}, {Subject: "bob", Secret: "b"}})
+	s.Remove("alice")
+
+	assert.Len(t, s.List(), 1)
+	_, ok := s.Find("a")
+	assert.False(t, ok)
+	_, ok = s.Find("b")
+	assert.True(t, ok)
+}
+
+func TestCredentialStore_RemoveUnknownIsNoop(t *testing.T) {
+	s := NewCredentialStore([]Credential{{Subject: "alice", Secret: "a"}})
+	s.Remove("nobody")
+	assert.Len(t, s.List(), 1)
+}
+
+func TestCredentialStore_ResetDiscardsAdditions(t *testing.T) {
+	s := NewCredentialStore([]Credential{{Subject: "alice", Secret: "a"}})
+	s.Add(Credential{Subject: "bob", Secret: "b"})
+	s.Remove("alice")
+
+	s.Reset()
+
+	assert.Len(t, s.List(), 1)
+	_, ok := s.Find("a")
+	assert.True(t, ok)
+	_, ok = s.Find("b")
+	assert.False(t, ok)
+}
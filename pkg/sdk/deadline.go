@@ -0,0 +1,53 @@
+package sdk
+
+import (
+	"sync"
+	"time"
+)
+
+// deadline is a resettable expiry signal in the style of net.Conn's
+// SetReadDeadline/SetWriteDeadline: Set arms (or disarms) a timer, and a
+// channel closes when it fires so callers can observe expiry with
+// select instead of polling time.Now().
+type deadline struct {
+	mu    sync.Mutex
+	timer *time.Timer
+	ch    chan struct{}
+}
+
+// newDeadline returns a deadline with no expiry set.
+func newDeadline() *deadline {
+	return &deadline{ch: make(chan struct{})}
+}
+
+// set arms the deadline to fire at t, or disarms it and starts a fresh
+// one if t is the zero Time.
+func (d *deadline) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.ch = make(chan struct{})
+	if t.IsZero() {
+		return
+	}
+
+	ch := d.ch
+	if dur := time.Until(t); dur <= 0 {
+		close(ch)
+	} else {
+		d.timer = time.AfterFunc(dur, func() { close(ch) })
+	}
+}
+
+// wait returns the channel that closes when the deadline fires. The
+// channel is replaced each time set is called, so callers must re-fetch
+// it rather than cache it across calls.
+func (d *deadline) wait() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.ch
+}
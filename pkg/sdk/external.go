@@ -2,6 +2,7 @@ package sdk
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,24 +10,50 @@ import (
 	"os/exec"
 	"strings"
 	"sync"
+	"time"
 )
 
 // ExternalPlugin implements the Plugin interface by proxying calls to
-// a subprocess over stdio using the JSON-line protocol.
+// a subprocess over stdio using the JSON-line protocol. A single reader
+// goroutine scans stdout and demuxes each line to the pending call it
+// answers (by Response.ID) or, for Notification frames, to the
+// registered notify callback; this lets multiple requests be in flight
+// at once instead of serializing every round trip.
 type ExternalPlugin struct {
-	cmd    *exec.Cmd
-	stdin  io.WriteCloser
-	stdout *bufio.Scanner
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
 
-	mu   sync.Mutex // serializes requests
-	next int        // next request ID
+	writeMu sync.Mutex // serializes stdin writes only
 
-	info PluginInfo // cached after first Info() call
+	mu       sync.Mutex // guards next and pending
+	next     int        // next request ID
+	pending  map[int]chan *Response
+	closed   bool
+	closeErr error         // set once, when the reader loop exits
+	closedCh chan struct{} // closed once the reader loop exits
+
+	infoMu   sync.Mutex     // guards info and manifest
+	info     PluginInfo     // cached after first successful info call
+	manifest PluginManifest // cached alongside info
+
+	notifyMu sync.Mutex
+	notifyFn func(Notification) // set via SetNotifyFunc, may be nil
+
+	readDeadline  *deadline
+	writeDeadline *deadline
 }
 
 // StartExternalPlugin spawns the plugin subprocess and returns an adapter
-// that implements the Plugin interface.
+// that implements the Plugin interface. The subprocess's stderr is
+// discarded; use StartExternalPluginWithStderr to capture it.
 func StartExternalPlugin(command []string) (*ExternalPlugin, error) {
+	return StartExternalPluginWithStderr(command, io.Discard)
+}
+
+// StartExternalPluginWithStderr behaves like StartExternalPlugin but
+// forwards the subprocess's stderr to w instead of discarding it, for
+// callers (e.g. a supervisor) that want to capture and tag it.
+func StartExternalPluginWithStderr(command []string, stderr io.Writer) (*ExternalPlugin, error) {
 	if len(command) == 0 {
 		return nil, fmt.Errorf("empty command")
 	}
@@ -39,36 +66,142 @@ func StartExternalPlugin(command []string) (*ExternalPlugin, error) {
 	if err != nil {
 		return nil, fmt.Errorf("stdout pipe: %w", err)
 	}
-	// Forward plugin stderr to host stderr for debugging.
-	cmd.Stderr = nil // inherits parent stderr by default when nil... actually no.
-	// Let's explicitly pipe stderr through.
-	cmd.Stderr = writerFunc(func(p []byte) (int, error) {
-		// Could log or prefix, but for now just pass through.
-		return len(p), nil // discard plugin stderr to avoid noise
-	})
+	cmd.Stderr = stderr
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("starting plugin: %w", err)
 	}
 	scanner := bufio.NewScanner(stdout)
 	scanner.Buffer(make([]byte, 0, 1024*1024), 10*1024*1024)
-	return &ExternalPlugin{
-		cmd:    cmd,
-		stdin:  stdin,
-		stdout: scanner,
-		next:   1,
-	}, nil
+
+	e := &ExternalPlugin{
+		cmd:           cmd,
+		stdin:         stdin,
+		next:          1,
+		pending:       make(map[int]chan *Response),
+		closedCh:      make(chan struct{}),
+		readDeadline:  newDeadline(),
+		writeDeadline: newDeadline(),
+	}
+	go e.readLoop(scanner)
+	return e, nil
 }
 
-type writerFunc func([]byte) (int, error)
+// readLoop owns stdout for the lifetime of the subprocess. It dispatches
+// each line to the response channel registered for its id, or to the
+// notify callback if the line is a Notification (zero id, non-empty
+// method). When the subprocess exits or stdout closes, every still-
+// pending call is failed and the plugin is marked closed so that later
+// calls fail fast instead of hanging.
+func (e *ExternalPlugin) readLoop(scanner *bufio.Scanner) {
+	for scanner.Scan() {
+		var line struct {
+			ID     int             `json:"id"`
+			Method string          `json:"method"`
+			Result json.RawMessage `json:"result,omitempty"`
+			Error  string          `json:"error,omitempty"`
+			Params json.RawMessage `json:"params,omitempty"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			// Malformed line from the plugin; nothing sensible maps to a
+			// pending call, so drop it and keep reading.
+			continue
+		}
+		if line.ID == 0 && line.Method != "" {
+			e.dispatchNotification(Notification{Method: line.Method, Params: line.Params})
+			continue
+		}
+		e.deliver(line.ID, &Response{ID: line.ID, Result: line.Result, Error: line.Error})
+	}
 
-func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+	err := fmt.Errorf("plugin closed stdout unexpectedly")
+	if scanErr := scanner.Err(); scanErr != nil {
+		err = fmt.Errorf("reading plugin stdout: %w", scanErr)
+	}
 
-// call sends a request and reads the response. Must be called with mu held.
-func (e *ExternalPlugin) call(method string, params interface{}) (*Response, error) {
-	id := e.next
-	e.next++
+	e.mu.Lock()
+	e.closed = true
+	e.closeErr = err
+	pending := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+	close(e.closedCh)
+
+	for _, ch := range pending {
+		close(ch)
+	}
+}
+
+// Done returns a channel that's closed as soon as the subprocess's
+// stdout goes away, whether from a clean exit, a crash, or a killed
+// process. A supervisor selects on this to detect a crash immediately
+// instead of waiting for its next scheduled health ping.
+func (e *ExternalPlugin) Done() <-chan struct{} {
+	return e.closedCh
+}
+
+// deliver routes a response to the channel waiting for its id. A
+// response with no matching pending call (already timed out, or the
+// plugin double-replied) is discarded rather than crashing the reader.
+func (e *ExternalPlugin) deliver(id int, resp *Response) {
+	e.mu.Lock()
+	ch, ok := e.pending[id]
+	if ok {
+		delete(e.pending, id)
+	}
+	e.mu.Unlock()
+	if ok {
+		ch <- resp
+		close(ch)
+	}
+}
+
+// abandon removes id's pending channel without sending on it, for calls
+// that gave up waiting (deadline/context cancellation).
+func (e *ExternalPlugin) abandon(id int) {
+	e.mu.Lock()
+	if e.pending != nil {
+		delete(e.pending, id)
+	}
+	e.mu.Unlock()
+}
+
+// SetNotifyFunc implements Notifier. It registers the callback invoked
+// whenever the subprocess emits a Notification frame on stdout.
+func (e *ExternalPlugin) SetNotifyFunc(fn func(Notification)) {
+	e.notifyMu.Lock()
+	defer e.notifyMu.Unlock()
+	e.notifyFn = fn
+}
+
+func (e *ExternalPlugin) dispatchNotification(n Notification) {
+	e.notifyMu.Lock()
+	fn := e.notifyFn
+	e.notifyMu.Unlock()
+	if fn != nil {
+		fn(n)
+	}
+}
+
+// SetReadDeadline arms (or, with a zero Time, disarms) a deadline after
+// which any call awaiting a response fails with context.DeadlineExceeded.
+func (e *ExternalPlugin) SetReadDeadline(t time.Time) {
+	e.readDeadline.set(t)
+}
+
+// SetWriteDeadline arms (or, with a zero Time, disarms) a deadline after
+// which any call still writing its request to stdin fails with
+// context.DeadlineExceeded.
+func (e *ExternalPlugin) SetWriteDeadline(t time.Time) {
+	e.writeDeadline.set(t)
+}
 
-	req := Request{ID: id, Method: method}
+// call sends a request and waits for its response, the context being
+// cancelled, or either deadline firing - whichever comes first. Unlike
+// the old implementation it holds no lock across the round trip: e.mu
+// only guards id allocation and the pending map, so slow or stuck calls
+// no longer block unrelated requests to the same plugin.
+func (e *ExternalPlugin) call(ctx context.Context, method string, params interface{}) (*Response, error) {
+	req := Request{Method: method}
 	if params != nil {
 		data, err := json.Marshal(params)
 		if err != nil {
@@ -77,61 +210,124 @@ func (e *ExternalPlugin) call(method string, params interface{}) (*Response, err
 		req.Params = data
 	}
 
+	e.mu.Lock()
+	if e.closed {
+		closeErr := e.closeErr
+		e.mu.Unlock()
+		return nil, fmt.Errorf("plugin unavailable: %w", closeErr)
+	}
+	id := e.next
+	e.next++
+	req.ID = id
+	ch := make(chan *Response, 1)
+	e.pending[id] = ch
+	e.mu.Unlock()
+
 	line, err := json.Marshal(req)
 	if err != nil {
+		e.abandon(id)
 		return nil, fmt.Errorf("marshaling request: %w", err)
 	}
 	line = append(line, '\n')
-	if _, err := e.stdin.Write(line); err != nil {
-		return nil, fmt.Errorf("writing to plugin stdin: %w", err)
-	}
 
-	if !e.stdout.Scan() {
-		if err := e.stdout.Err(); err != nil {
-			return nil, fmt.Errorf("reading plugin stdout: %w", err)
+	writeErrCh := make(chan error, 1)
+	go func() {
+		e.writeMu.Lock()
+		defer e.writeMu.Unlock()
+		_, err := e.stdin.Write(line)
+		writeErrCh <- err
+	}()
+
+	select {
+	case err := <-writeErrCh:
+		if err != nil {
+			e.abandon(id)
+			return nil, fmt.Errorf("writing to plugin stdin: %w", err)
 		}
-		return nil, fmt.Errorf("plugin closed stdout unexpectedly")
+	case <-ctx.Done():
+		e.abandon(id)
+		return nil, fmt.Errorf("plugin call %q: %w", method, ctx.Err())
+	case <-e.writeDeadline.wait():
+		e.abandon(id)
+		return nil, fmt.Errorf("plugin call %q: %w", method, context.DeadlineExceeded)
 	}
 
-	var resp Response
-	if err := json.Unmarshal(e.stdout.Bytes(), &resp); err != nil {
-		return nil, fmt.Errorf("unmarshaling response: %w", err)
-	}
-	if resp.Error != "" {
-		return nil, fmt.Errorf("plugin error: %s", resp.Error)
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			e.mu.Lock()
+			closeErr := e.closeErr
+			e.mu.Unlock()
+			return nil, fmt.Errorf("plugin call %q: %w", method, closeErr)
+		}
+		if resp.Error != "" {
+			return nil, fmt.Errorf("plugin error: %s", resp.Error)
+		}
+		return resp, nil
+	case <-ctx.Done():
+		e.abandon(id)
+		return nil, fmt.Errorf("plugin call %q: %w", method, ctx.Err())
+	case <-e.readDeadline.wait():
+		e.abandon(id)
+		return nil, fmt.Errorf("plugin call %q: %w", method, context.DeadlineExceeded)
 	}
-	return &resp, nil
 }
 
 // Info implements Plugin.
 func (e *ExternalPlugin) Info() PluginInfo {
+	e.infoMu.Lock()
+	defer e.infoMu.Unlock()
 	return e.info
 }
 
-// Configure implements Plugin.
-func (e *ExternalPlugin) Configure(env map[string]string) error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+// Manifest implements Plugin. It returns whatever was cached by the
+// last successful refreshInfo, so callers that need it before Configure
+// (e.g. internal/engine's admission step, via the Ping during the
+// supervisor's launch handshake) see it as soon as the subprocess has
+// answered one "info" call.
+func (e *ExternalPlugin) Manifest() PluginManifest {
+	e.infoMu.Lock()
+	defer e.infoMu.Unlock()
+	return e.manifest
+}
 
-	// First, call info to cache plugin metadata.
-	resp, err := e.call("info", nil)
+// refreshInfo calls "info" and caches the plugin's metadata and
+// manifest. It's used both by Configure, which needs fresh info before
+// configuring, and by Ping, so a health check also keeps the cached
+// manifest current across restarts. Ping runs off the supervisor's
+// health-check goroutine, concurrently with any Info/Manifest call an
+// admin-panel request might make, so infoMu guards both the writes here
+// and those getters' reads.
+func (e *ExternalPlugin) refreshInfo(ctx context.Context) error {
+	resp, err := e.call(ctx, "info", nil)
 	if err != nil {
 		return fmt.Errorf("getting plugin info: %w", err)
 	}
-	if err := json.Unmarshal(resp.Result, &e.info); err != nil {
+	var result InfoResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
 		return fmt.Errorf("unmarshaling plugin info: %w", err)
 	}
+	e.infoMu.Lock()
+	e.info = result.PluginInfo
+	e.manifest = result.Manifest
+	e.infoMu.Unlock()
+	return nil
+}
 
-	// Then configure.
-	_, err = e.call("configure", ConfigureParams{Env: env})
+// Configure implements Plugin.
+func (e *ExternalPlugin) Configure(env map[string]string) error {
+	ctx := context.Background()
+	if err := e.refreshInfo(ctx); err != nil {
+		return err
+	}
+	_, err := e.call(ctx, "configure", ConfigureParams{Env: env})
 	return err
 }
 
-// ServeHTTP implements Plugin (http.Handler).
+// ServeHTTP implements Plugin (http.Handler). The request's context
+// bounds the call, so a client timeout or disconnect unblocks the
+// subprocess round trip instead of leaking it.
 func (e *ExternalPlugin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("reading body: %v", err), http.StatusInternalServerError)
@@ -151,7 +347,7 @@ func (e *ExternalPlugin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		params.Headers[k] = r.Header.Get(k)
 	}
 
-	resp, err := e.call("http", params)
+	resp, err := e.call(r.Context(), "http", params)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
@@ -174,14 +370,56 @@ func (e *ExternalPlugin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // Reset implements Plugin.
 func (e *ExternalPlugin) Reset() error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	_, err := e.call("reset", nil)
+	_, err := e.call(context.Background(), "reset", nil)
+	return err
+}
+
+// Snapshot implements Snapshotter by asking the subprocess to serialize
+// its current state.
+func (e *ExternalPlugin) Snapshot() (json.RawMessage, error) {
+	resp, err := e.call(context.Background(), "snapshot", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+// Restore implements Snapshotter by asking the subprocess to reapply a
+// previously captured (or hand-authored) snapshot.
+func (e *ExternalPlugin) Restore(data json.RawMessage) error {
+	_, err := e.call(context.Background(), "restore", data)
 	return err
 }
 
-// Stop terminates the plugin subprocess.
+// Ping issues a live "info" round trip to the subprocess, for health
+// checks that want to confirm it's still responsive rather than trust
+// the PluginInfo cached at Configure time. It also refreshes the cached
+// info and manifest, which is how the supervisor's launch handshake
+// makes a freshly started plugin's manifest available before Configure
+// is ever called.
+func (e *ExternalPlugin) Ping(ctx context.Context) error {
+	return e.refreshInfo(ctx)
+}
+
+// Stop terminates the plugin subprocess. Closing stdin signals the
+// subprocess to exit; once it does, the reader goroutine observes EOF
+// and fails any still-pending calls on its own.
 func (e *ExternalPlugin) Stop() error {
 	e.stdin.Close()
 	return e.cmd.Wait()
 }
+
+// StopGraceful behaves like Stop but doesn't wait indefinitely: if the
+// subprocess hasn't exited within grace, it's sent SIGKILL.
+func (e *ExternalPlugin) StopGraceful(grace time.Duration) error {
+	e.stdin.Close()
+	done := make(chan error, 1)
+	go func() { done <- e.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(grace):
+		e.cmd.Process.Kill()
+		return <-done
+	}
+}
@@ -0,0 +1,235 @@
+// Package faults wraps an http.Handler with configurable failure modes
+// so integration tests can exercise the error paths of an otherwise
+// well-behaved fake: added latency, error-rate injection, body
+// truncation, connection drops, and per-route rate limiting. A Config
+// is inert until installed with Middleware.SetConfig, which is safe to
+// call concurrently with in-flight requests so it can be driven by a
+// runtime toggle endpoint.
+package faults
+
+import (
+	"math/rand"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit returns 429 with a Retry-After header once more than
+// Requests requests have been seen within Per.
+type RateLimit struct {
+	Requests   int           `json:"requests"`
+	Per        time.Duration `json:"per"`
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+}
+
+// Rule is one set of fault behaviors applied to a request. The zero
+// Rule injects nothing.
+type Rule struct {
+	// Latency is added before the request is served.
+	Latency time.Duration `json:"latency,omitempty"`
+	// LatencyJitter adds a random extra delay in [0, LatencyJitter) on
+	// top of Latency.
+	LatencyJitter time.Duration `json:"latency_jitter,omitempty"`
+	// ErrorRate is the probability (0..1) that the request is failed
+	// with Status and Body instead of being served.
+	ErrorRate float64 `json:"error_rate,omitempty"`
+	Status    int     `json:"status,omitempty"`
+	Body      string  `json:"body,omitempty"`
+	// Truncate, if > 0, cuts the response body to this many bytes.
+	Truncate int `json:"truncate,omitempty"`
+	// Drop is the probability (0..1) that the connection is hijacked
+	// and closed without a response, simulating a dropped connection.
+	Drop      float64    `json:"drop,omitempty"`
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+}
+
+// RouteRule overrides Config's default Rule for requests matching
+// Method (empty matches any) and Path, a path.Match glob evaluated
+// against the request's URL path.
+type RouteRule struct {
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path"`
+	Rule
+}
+
+// Config is the full fault configuration for one plugin: a default Rule
+// applied to every request, plus Routes checked in order first so a
+// scenario can target a single endpoint without affecting the rest.
+type Config struct {
+	Rule
+	Routes []RouteRule `json:"routes,omitempty"`
+}
+
+func (c *Config) ruleFor(r *http.Request) (Rule, int) {
+	for i, route := range c.Routes {
+		if route.Method != "" && route.Method != r.Method {
+			continue
+		}
+		if ok, _ := path.Match(route.Path, r.URL.Path); ok {
+			return route.Rule, i
+		}
+	}
+	return c.Rule, -1
+}
+
+// Middleware wraps an http.Handler, applying whatever Config is
+// currently installed. With no Config installed it is a pass-through.
+type Middleware struct {
+	next http.Handler
+
+	mu     sync.RWMutex
+	cfg    *Config
+	base   *limiter
+	routes []*limiter
+}
+
+// Wrap returns a Middleware delegating to next when no Config is set.
+func Wrap(next http.Handler) *Middleware {
+	return &Middleware{next: next}
+}
+
+// SetConfig installs cfg as the active fault configuration, replacing
+// any rate limiter state. A nil cfg disables fault injection.
+func (m *Middleware) SetConfig(cfg *Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cfg = cfg
+	m.base = nil
+	m.routes = nil
+	if cfg == nil {
+		return
+	}
+	if cfg.RateLimit != nil {
+		m.base = newLimiter(cfg.RateLimit.Requests, cfg.RateLimit.Per)
+	}
+	m.routes = make([]*limiter, len(cfg.Routes))
+	for i, route := range cfg.Routes {
+		if route.RateLimit != nil {
+			m.routes[i] = newLimiter(route.RateLimit.Requests, route.RateLimit.Per)
+		}
+	}
+}
+
+// Config returns the currently installed Config, or nil if none is set.
+func (m *Middleware) Config() *Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cfg
+}
+
+func (m *Middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.RLock()
+	cfg := m.cfg
+	base := m.base
+	routes := m.routes
+	m.mu.RUnlock()
+
+	if cfg == nil {
+		m.next.ServeHTTP(w, r)
+		return
+	}
+
+	rule, idx := cfg.ruleFor(r)
+	lim := base
+	if idx >= 0 {
+		lim = routes[idx]
+	}
+
+	if lim != nil && !lim.Allow() {
+		retryAfter := rule.RateLimit.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = rule.RateLimit.Per
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"message":"rate limit exceeded"}`))
+		return
+	}
+
+	if rule.Latency > 0 || rule.LatencyJitter > 0 {
+		d := rule.Latency
+		if rule.LatencyJitter > 0 {
+			d += time.Duration(rand.Int63n(int64(rule.LatencyJitter)))
+		}
+		select {
+		case <-time.After(d):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if rule.Drop > 0 && rand.Float64() < rule.Drop {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+
+	if rule.ErrorRate > 0 && rand.Float64() < rule.ErrorRate {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(rule.Status)
+		w.Write([]byte(rule.Body))
+		return
+	}
+
+	if rule.Truncate > 0 {
+		m.next.ServeHTTP(&truncatingWriter{ResponseWriter: w, limit: rule.Truncate}, r)
+		return
+	}
+
+	m.next.ServeHTTP(w, r)
+}
+
+// truncatingWriter cuts off a response body after limit bytes, while
+// still reporting every byte as written so callers (e.g. json.Encoder)
+// don't fail on what looks to them like a short write - the truncation
+// is meant to surface at the client, the way a real flaky API would.
+type truncatingWriter struct {
+	http.ResponseWriter
+	limit   int
+	written int
+}
+
+func (t *truncatingWriter) Write(p []byte) (int, error) {
+	if t.written >= t.limit {
+		return len(p), nil
+	}
+	keep := t.limit - t.written
+	if keep > len(p) {
+		keep = len(p)
+	}
+	n, err := t.ResponseWriter.Write(p[:keep])
+	t.written += n
+	return len(p), err
+}
+
+// limiter is a fixed-window request counter.
+type limiter struct {
+	mu          sync.Mutex
+	limit       int
+	per         time.Duration
+	count       int
+	windowStart time.Time
+}
+
+func newLimiter(limit int, per time.Duration) *limiter {
+	return &limiter{limit: limit, per: per, windowStart: time.Now()}
+}
+
+func (l *limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if now.Sub(l.windowStart) >= l.per {
+		l.windowStart = now
+		l.count = 0
+	}
+	l.count++
+	return l.count <= l.limit
+}
@@ -0,0 +1,145 @@
+package faults
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("hello"))
+}
+
+func TestMiddleware_NoConfigPassesThrough(t *testing.T) {
+	m := Wrap(http.HandlerFunc(ok))
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+}
+
+func TestMiddleware_ErrorRateOne_AlwaysFails(t *testing.T) {
+	m := Wrap(http.HandlerFunc(ok))
+	m.SetConfig(&Config{Rule: Rule{ErrorRate: 1, Status: http.StatusBadGateway, Body: `{"error":"boom"}`}})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, `{"error":"boom"}`, w.Body.String())
+}
+
+func TestMiddleware_ErrorRateZero_NeverFails(t *testing.T) {
+	m := Wrap(http.HandlerFunc(ok))
+	m.SetConfig(&Config{Rule: Rule{ErrorRate: 0, Status: http.StatusBadGateway}})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMiddleware_Truncate(t *testing.T) {
+	m := Wrap(http.HandlerFunc(ok))
+	m.SetConfig(&Config{Rule: Rule{Truncate: 2}})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "he", w.Body.String())
+}
+
+func TestMiddleware_Latency(t *testing.T) {
+	m := Wrap(http.HandlerFunc(ok))
+	m.SetConfig(&Config{Rule: Rule{Latency: 20 * time.Millisecond}})
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMiddleware_RouteRuleOverridesDefault(t *testing.T) {
+	m := Wrap(http.HandlerFunc(ok))
+	m.SetConfig(&Config{
+		Rule: Rule{ErrorRate: 1, Status: http.StatusInternalServerError},
+		Routes: []RouteRule{
+			{Method: http.MethodGet, Path: "/healthy", Rule: Rule{}},
+		},
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthy", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w2 := httptest.NewRecorder()
+	m.ServeHTTP(w2, httptest.NewRequest(http.MethodGet, "/other", nil))
+	assert.Equal(t, http.StatusInternalServerError, w2.Code)
+}
+
+func TestMiddleware_RouteRuleMethodMismatchFallsBackToDefault(t *testing.T) {
+	m := Wrap(http.HandlerFunc(ok))
+	m.SetConfig(&Config{
+		Rule: Rule{ErrorRate: 1, Status: http.StatusInternalServerError},
+		Routes: []RouteRule{
+			{Method: http.MethodPost, Path: "/x", Rule: Rule{}},
+		},
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestMiddleware_RateLimit(t *testing.T) {
+	m := Wrap(http.HandlerFunc(ok))
+	m.SetConfig(&Config{Rule: Rule{RateLimit: &RateLimit{Requests: 1, Per: time.Minute, RetryAfter: 5 * time.Second}}})
+
+	w1 := httptest.NewRecorder()
+	m.ServeHTTP(w1, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w1.Code)
+
+	w2 := httptest.NewRecorder()
+	m.ServeHTTP(w2, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w2.Code)
+	assert.Equal(t, "5", w2.Header().Get("Retry-After"))
+}
+
+func TestMiddleware_ConfigRoundTrips(t *testing.T) {
+	m := Wrap(http.HandlerFunc(ok))
+	assert.Nil(t, m.Config())
+
+	cfg := &Config{Rule: Rule{Latency: time.Second}}
+	m.SetConfig(cfg)
+	assert.Same(t, cfg, m.Config())
+
+	m.SetConfig(nil)
+	assert.Nil(t, m.Config())
+}
+
+func TestLimiter_ResetsAfterWindow(t *testing.T) {
+	l := newLimiter(1, 10*time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	time.Sleep(15 * time.Millisecond)
+	assert.True(t, l.Allow())
+}
+
+func TestTruncatingWriter_ReportsFullLengthWritten(t *testing.T) {
+	w := httptest.NewRecorder()
+	tw := &truncatingWriter{ResponseWriter: w, limit: 3}
+
+	n, err := tw.Write([]byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n, "must report the full length, not the truncated one")
+	assert.Equal(t, "hel", w.Body.String())
+
+	n, err = tw.Write([]byte("world"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, "hel", w.Body.String(), "writes past the limit contribute nothing further")
+}
@@ -1,7 +1,10 @@
 // Package sdk defines the public plugin interface for DoubleAgent.
 package sdk
 
-import "net/http"
+import (
+	"encoding/json"
+	"net/http"
+)
 
 // PluginInfo holds metadata about a plugin.
 type PluginInfo struct {
@@ -15,6 +18,14 @@ type Plugin interface {
 	// Info returns plugin metadata.
 	Info() PluginInfo
 
+	// Manifest declares what the plugin needs from its host: required
+	// env vars, the ports it wants to bind, any hosts it contacts
+	// outbound, and the protocol version it was built against.
+	// internal/engine's admission step checks this before Configure is
+	// ever called, so a plugin can't silently ask for more than it
+	// declared.
+	Manifest() PluginManifest
+
 	// Configure passes environment config to the plugin.
 	Configure(env map[string]string) error
 
@@ -24,3 +35,83 @@ type Plugin interface {
 	// Reset clears all in-memory state.
 	Reset() error
 }
+
+// Notifier is implemented by plugins that push events to the host outside
+// of the normal request-response cycle, such as outbound webhooks fired
+// when a fake mutates state. The host calls SetNotifyFunc once, after
+// Configure, and the plugin invokes the callback whenever it has a
+// Notification to deliver.
+type Notifier interface {
+	// SetNotifyFunc registers the callback used to enqueue notifications.
+	SetNotifyFunc(func(Notification))
+}
+
+// APIVersion is the plugin/host protocol version implemented by this
+// SDK. A manifest's APIVersion is checked against it verbatim by
+// internal/engine's admission step; there's no compatibility range yet,
+// just an exact match, since the wire protocol hasn't needed to change
+// since v1.
+const APIVersion = "1"
+
+// EnvVar describes one environment variable a plugin expects to be
+// configured with.
+type EnvVar struct {
+	Name string `json:"name"`
+	// Secret marks a value that shouldn't be echoed back in logs or
+	// displayed by admin tooling, e.g. an API token rather than a
+	// default org name.
+	Secret bool `json:"secret,omitempty"`
+}
+
+// PortRange bounds the ports a plugin may ask to bind, inclusive. A zero
+// value imposes no bound.
+type PortRange struct {
+	Min int `json:"min,omitempty"`
+	Max int `json:"max,omitempty"`
+}
+
+// PluginManifest describes what a plugin needs from its host: its
+// required environment, the ports and outbound hosts it uses, and the
+// protocol version it was built against. It's also the config blob of
+// the OCI artifact that internal/pluginstore pulls, so an installed
+// external plugin's declared privileges are visible before it's ever
+// run. Built-in plugins return it from Manifest(); external plugins
+// return it as part of their "info" response.
+type PluginManifest struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	APIVersion string `json:"api_version,omitempty"`
+	// Type is the config.Service.Type this bundle implements, e.g.
+	// "github" or "jira". internal/engine refuses to launch a bundle
+	// whose declared Type disagrees with the service's, so a
+	// content-addressable ref can't silently stand in for the wrong
+	// kind of fake. Empty skips the check, for manifests predating it.
+	Type string `json:"type,omitempty"`
+	// Entrypoint is the command internal/pluginstore resolves an
+	// installed plugin's launch command to; empty for built-ins, which
+	// the engine instead constructs from internal/builtin.Registry.
+	Entrypoint []string `json:"entrypoint,omitempty"`
+	// RequiredEnv lists the env vars the plugin needs Configure to
+	// receive. internal/engine refuses to start a service missing any
+	// of these, rather than let the plugin fail confusingly later.
+	RequiredEnv []EnvVar `json:"required_env,omitempty"`
+	// PortRange is the range of ports the plugin wants to bind.
+	PortRange PortRange `json:"port_range,omitempty"`
+	// AllowOutbound lists hosts the plugin contacts outbound (e.g. a
+	// fake that itself calls another fake). A double.hcl grant block
+	// can restrict this below what the plugin declares; it's a
+	// declaration, not sandboxing the process itself.
+	AllowOutbound []string `json:"allow_outbound,omitempty"`
+}
+
+// Snapshotter is implemented by plugins whose state can be captured and
+// later reapplied, so a scenario can be seeded at startup or recorded
+// mid-test and restored afterward. The host treats the serialized form
+// as opaque; its shape is entirely up to the plugin.
+type Snapshotter interface {
+	// Snapshot returns the plugin's current state, serialized to JSON.
+	Snapshot() (json.RawMessage, error)
+	// Restore replaces the plugin's state with a previously captured
+	// Snapshot, or with hand-authored seed data of the same shape.
+	Restore(json.RawMessage) error
+}
@@ -16,6 +16,14 @@ type Response struct {
 	Error  string          `json:"error,omitempty"`
 }
 
+// InfoResult is the result of the "info" method: plugin metadata plus
+// its declared manifest, so a single round trip gives the host enough
+// to run its admission checks before ever calling "configure".
+type InfoResult struct {
+	PluginInfo
+	Manifest PluginManifest `json:"manifest"`
+}
+
 // ConfigureParams are the parameters for the "configure" method.
 type ConfigureParams struct {
 	Env map[string]string `json:"env"`
@@ -35,3 +43,21 @@ type HTTPResult struct {
 	Headers map[string]string `json:"headers,omitempty"`
 	Body    string            `json:"body"`
 }
+
+// Notification is a JSON-line message a plugin sends to the host without
+// a corresponding request, e.g. to push a webhook event as it happens.
+// Unlike Response it carries no id; the host's reader loop tells the two
+// apart by the absent/zero id on the decoded line.
+type Notification struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// WebhookParams are the parameters of a "webhook" notification: a single
+// outbound HTTP delivery the host should make on the plugin's behalf.
+type WebhookParams struct {
+	Event   string            `json:"event"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+}
@@ -10,13 +10,31 @@ import (
 	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 )
 
 // Serve runs a Plugin as an external stdio plugin. It reads JSON requests from
 // stdin, dispatches them to the plugin, and writes JSON responses to stdout.
 // This function blocks until stdin is closed.
+//
+// If p implements Notifier, Serve registers a notify callback so the plugin
+// can emit Notification frames on stdout at any time, interleaved with
+// request/response traffic; a mutex guards stdout so the two never tear.
 func Serve(p Plugin) {
+	var stdoutMu sync.Mutex
 	enc := json.NewEncoder(os.Stdout)
+	encode := func(v interface{}) {
+		stdoutMu.Lock()
+		defer stdoutMu.Unlock()
+		enc.Encode(v)
+	}
+
+	if notifier, ok := p.(Notifier); ok {
+		notifier.SetNotifyFunc(func(n Notification) {
+			encode(n)
+		})
+	}
+
 	scanner := bufio.NewScanner(os.Stdin)
 	scanner.Buffer(make([]byte, 0, 1024*1024), 10*1024*1024)
 	for scanner.Scan() {
@@ -26,11 +44,11 @@ func Serve(p Plugin) {
 		}
 		var req Request
 		if err := json.Unmarshal(line, &req); err != nil {
-			enc.Encode(Response{Error: fmt.Sprintf("invalid request: %v", err)})
+			encode(Response{Error: fmt.Sprintf("invalid request: %v", err)})
 			continue
 		}
 		resp := dispatch(p, req)
-		enc.Encode(resp)
+		encode(resp)
 	}
 }
 
@@ -44,14 +62,18 @@ func dispatch(p Plugin, req Request) Response {
 		return handleHTTP(p, req)
 	case "reset":
 		return handleReset(p, req)
+	case "snapshot":
+		return handleSnapshot(p, req)
+	case "restore":
+		return handleRestore(p, req)
 	default:
 		return Response{ID: req.ID, Error: fmt.Sprintf("unknown method: %q", req.Method)}
 	}
 }
 
 func handleInfo(p Plugin, req Request) Response {
-	info := p.Info()
-	data, _ := json.Marshal(info)
+	result := InfoResult{PluginInfo: p.Info(), Manifest: p.Manifest()}
+	data, _ := json.Marshal(result)
 	return Response{ID: req.ID, Result: data}
 }
 
@@ -107,3 +129,27 @@ func handleReset(p Plugin, req Request) Response {
 	data, _ := json.Marshal(struct{}{})
 	return Response{ID: req.ID, Result: data}
 }
+
+func handleSnapshot(p Plugin, req Request) Response {
+	snapshotter, ok := p.(Snapshotter)
+	if !ok {
+		return Response{ID: req.ID, Error: "plugin does not support snapshotting"}
+	}
+	data, err := snapshotter.Snapshot()
+	if err != nil {
+		return Response{ID: req.ID, Error: err.Error()}
+	}
+	return Response{ID: req.ID, Result: data}
+}
+
+func handleRestore(p Plugin, req Request) Response {
+	snapshotter, ok := p.(Snapshotter)
+	if !ok {
+		return Response{ID: req.ID, Error: "plugin does not support snapshotting"}
+	}
+	if err := snapshotter.Restore(req.Params); err != nil {
+		return Response{ID: req.ID, Error: err.Error()}
+	}
+	data, _ := json.Marshal(struct{}{})
+	return Response{ID: req.ID, Result: data}
+}
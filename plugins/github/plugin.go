@@ -5,15 +5,24 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/islo-labs/double-agent/pkg/sdk"
+	"github.com/islo-labs/double-agent/pkg/sdk/auth"
 )
 
+// webhookEnvPrefix matches internal/engine's convention of folding
+// per-event webhook targets into the plugin's env.
+const webhookEnvPrefix = "WEBHOOK_URL_"
+
 // GitHubPlugin is a fake GitHub API service.
 type GitHubPlugin struct {
 	store      *Store
 	router     *http.ServeMux
 	defaultOrg string
+
+	webhookURLs map[string]string // event -> URL
+	notify      func(sdk.Notification)
 }
 
 // New creates a new GitHubPlugin.
@@ -27,13 +36,66 @@ func (p *GitHubPlugin) Info() sdk.PluginInfo {
 	return sdk.PluginInfo{Name: "github", Version: "v1"}
 }
 
+func (p *GitHubPlugin) Manifest() sdk.PluginManifest {
+	return sdk.PluginManifest{Name: "github", Version: "v1", APIVersion: sdk.APIVersion}
+}
+
 func (p *GitHubPlugin) Configure(env map[string]string) error {
 	if org, ok := env["DEFAULT_ORG"]; ok {
 		p.defaultOrg = org
 	}
+	for k, v := range env {
+		if event, ok := strings.CutPrefix(k, webhookEnvPrefix); ok {
+			if p.webhookURLs == nil {
+				p.webhookURLs = make(map[string]string)
+			}
+			p.webhookURLs[event] = v
+		}
+	}
 	return nil
 }
 
+// SetNotifyFunc implements sdk.Notifier.
+func (p *GitHubPlugin) SetNotifyFunc(fn func(sdk.Notification)) {
+	p.notify = fn
+}
+
+// emitWebhook pushes a "webhook" notification for event if a target URL is
+// configured for it and the host has registered a notify callback.
+func (p *GitHubPlugin) emitWebhook(event string, payload any) {
+	if p.notify == nil {
+		return
+	}
+	url, ok := p.webhookURLs[event]
+	if !ok {
+		return
+	}
+	body, err := json.Marshal(map[string]any{
+		"action":  event,
+		"payload": payload,
+	})
+	if err != nil {
+		return
+	}
+	params, err := json.Marshal(sdk.WebhookParams{
+		Event: event,
+		URL:   url,
+		Body:  string(body),
+	})
+	if err != nil {
+		return
+	}
+	p.notify(sdk.Notification{Method: "webhook", Params: params})
+}
+
+// authorizedForScope reports whether the request may proceed: either no
+// auth.Middleware ran for this service (no Principal on the context), or
+// it did and the resolved Principal carries scope.
+func authorizedForScope(r *http.Request, scope string) bool {
+	principal, ok := auth.FromContext(r.Context())
+	return !ok || principal.HasScope(scope)
+}
+
 func (p *GitHubPlugin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	p.router.ServeHTTP(w, r)
 }
@@ -43,6 +105,16 @@ func (p *GitHubPlugin) Reset() error {
 	return nil
 }
 
+// Snapshot implements sdk.Snapshotter.
+func (p *GitHubPlugin) Snapshot() (json.RawMessage, error) {
+	return p.store.Snapshot()
+}
+
+// Restore implements sdk.Snapshotter.
+func (p *GitHubPlugin) Restore(data json.RawMessage) error {
+	return p.store.Restore(data)
+}
+
 func (p *GitHubPlugin) setupRoutes() {
 	p.router = http.NewServeMux()
 	p.router.HandleFunc("POST /repos", p.createRepo)
@@ -60,6 +132,10 @@ type createRepoRequest struct {
 }
 
 func (p *GitHubPlugin) createRepo(w http.ResponseWriter, r *http.Request) {
+	if !authorizedForScope(r, "repo") {
+		http.Error(w, `{"message":"Resource not accessible by integration"}`, http.StatusForbidden)
+		return
+	}
 	var req createRepoRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, `{"message":"invalid JSON"}`, http.StatusBadRequest)
@@ -115,6 +191,7 @@ func (p *GitHubPlugin) createIssue(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(issue)
+	p.emitWebhook("issues", issue)
 }
 
 func (p *GitHubPlugin) listIssues(w http.ResponseWriter, r *http.Request) {
@@ -151,6 +228,7 @@ func (p *GitHubPlugin) createPR(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(pr)
+	p.emitWebhook("pull_request", pr)
 }
 
 func (p *GitHubPlugin) getPR(w http.ResponseWriter, r *http.Request) {
@@ -1,6 +1,7 @@
 package github
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
@@ -184,3 +185,51 @@ func (s *Store) GetPullRequest(owner, repo string, number int) (*PullRequest, bo
 func repoKey(owner, name string) string {
 	return owner + "/" + name
 }
+
+// storeSnapshot is the serializable form of a Store's state.
+type storeSnapshot struct {
+	Repos  map[string]*Repo          `json:"repos"`
+	Issues map[string][]*Issue       `json:"issues"`
+	PRs    map[string][]*PullRequest `json:"prs"`
+	NextID int                       `json:"next_id"`
+}
+
+// Snapshot implements sdk.Snapshotter.
+func (s *Store) Snapshot() (json.RawMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return json.Marshal(storeSnapshot{
+		Repos:  s.repos,
+		Issues: s.issues,
+		PRs:    s.prs,
+		NextID: s.nextID,
+	})
+}
+
+// Restore implements sdk.Snapshotter, replacing the store's state wholesale.
+func (s *Store) Restore(data json.RawMessage) error {
+	var snap storeSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("unmarshaling snapshot: %w", err)
+	}
+	if snap.Repos == nil {
+		snap.Repos = make(map[string]*Repo)
+	}
+	if snap.Issues == nil {
+		snap.Issues = make(map[string][]*Issue)
+	}
+	if snap.PRs == nil {
+		snap.PRs = make(map[string][]*PullRequest)
+	}
+	if snap.NextID < 1 {
+		snap.NextID = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repos = snap.Repos
+	s.issues = snap.Issues
+	s.prs = snap.PRs
+	s.nextID = snap.NextID
+	return nil
+}
@@ -0,0 +1,249 @@
+// Package gitlab provides a fake GitLab v4 REST API plugin.
+package gitlab
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/islo-labs/double-agent/internal/forge"
+	"github.com/islo-labs/double-agent/pkg/sdk"
+)
+
+// GitLabPlugin is a fake GitLab API service.
+type GitLabPlugin struct {
+	store        *Store
+	router       *http.ServeMux
+	defaultGroup string
+}
+
+// New creates a new GitLabPlugin.
+func New() sdk.Plugin {
+	p := &GitLabPlugin{store: NewStore()}
+	p.setupRoutes()
+	return p
+}
+
+func (p *GitLabPlugin) Info() sdk.PluginInfo {
+	return sdk.PluginInfo{Name: "gitlab", Version: "v1"}
+}
+
+func (p *GitLabPlugin) Manifest() sdk.PluginManifest {
+	return sdk.PluginManifest{Name: "gitlab", Version: "v1", APIVersion: sdk.APIVersion}
+}
+
+func (p *GitLabPlugin) Configure(env map[string]string) error {
+	if group, ok := env["DEFAULT_GROUP"]; ok {
+		p.defaultGroup = group
+	}
+	return nil
+}
+
+func (p *GitLabPlugin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.router.ServeHTTP(w, r)
+}
+
+func (p *GitLabPlugin) Reset() error {
+	p.store.Reset()
+	return nil
+}
+
+func (p *GitLabPlugin) setupRoutes() {
+	p.router = http.NewServeMux()
+	p.router.HandleFunc("POST /api/v4/projects", p.createProject)
+	p.router.HandleFunc("GET /api/v4/projects/{id}", p.getProject)
+	p.router.HandleFunc("POST /api/v4/projects/{id}/issues", p.createIssue)
+	p.router.HandleFunc("GET /api/v4/projects/{id}/issues", p.listIssues)
+	p.router.HandleFunc("POST /api/v4/projects/{id}/merge_requests", p.createMergeRequest)
+	p.router.HandleFunc("GET /api/v4/projects/{id}/merge_requests/{iid}", p.getMergeRequest)
+	p.router.HandleFunc("POST /api/v4/projects/{id}/merge_requests/{iid}/notes", p.createNote)
+	p.router.HandleFunc("GET /api/v4/projects/{id}/merge_requests/{iid}/notes", p.listNotes)
+}
+
+// projectPathParam decodes the {id} path segment, which GitLab clients
+// send as a URL-encoded "namespace%2Fproject" path.
+func projectPathParam(r *http.Request) (string, error) {
+	return url.PathUnescape(r.PathValue("id"))
+}
+
+type createProjectRequest struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Visibility string `json:"visibility"`
+}
+
+func (p *GitLabPlugin) createProject(w http.ResponseWriter, r *http.Request) {
+	var req createProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"message":"invalid JSON"}`, http.StatusBadRequest)
+		return
+	}
+	if req.Namespace == "" {
+		req.Namespace = p.defaultGroup
+	}
+	if req.Namespace == "" || req.Name == "" {
+		http.Error(w, `{"message":"namespace and name are required"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	proj, err := p.store.CreateProject(req.Namespace, req.Name, req.Visibility == "private")
+	if err != nil {
+		http.Error(w, `{"message":"`+err.Error()+`"}`, http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(proj)
+}
+
+func (p *GitLabPlugin) getProject(w http.ResponseWriter, r *http.Request) {
+	path, err := projectPathParam(r)
+	if err != nil {
+		http.Error(w, `{"message":"invalid project id"}`, http.StatusBadRequest)
+		return
+	}
+	proj, ok := p.store.GetProject(path)
+	if !ok {
+		http.Error(w, `{"message":"404 Project Not Found"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(proj)
+}
+
+type createIssueRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+func (p *GitLabPlugin) createIssue(w http.ResponseWriter, r *http.Request) {
+	path, err := projectPathParam(r)
+	if err != nil {
+		http.Error(w, `{"message":"invalid project id"}`, http.StatusBadRequest)
+		return
+	}
+	var req createIssueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"message":"invalid JSON"}`, http.StatusBadRequest)
+		return
+	}
+	issue, err := p.store.CreateIssue(path, req.Title, req.Description)
+	if err != nil {
+		http.Error(w, `{"message":"`+err.Error()+`"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issue)
+}
+
+func (p *GitLabPlugin) listIssues(w http.ResponseWriter, r *http.Request) {
+	path, err := projectPathParam(r)
+	if err != nil {
+		http.Error(w, `{"message":"invalid project id"}`, http.StatusBadRequest)
+		return
+	}
+	issues := p.store.ListIssues(path)
+	if issues == nil {
+		issues = []*forge.Issue{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(issues)
+}
+
+type createMergeRequestRequest struct {
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+}
+
+func (p *GitLabPlugin) createMergeRequest(w http.ResponseWriter, r *http.Request) {
+	path, err := projectPathParam(r)
+	if err != nil {
+		http.Error(w, `{"message":"invalid project id"}`, http.StatusBadRequest)
+		return
+	}
+	var req createMergeRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"message":"invalid JSON"}`, http.StatusBadRequest)
+		return
+	}
+	mr, err := p.store.CreateMergeRequest(path, req.Title, req.Description, req.SourceBranch, req.TargetBranch)
+	if err != nil {
+		http.Error(w, `{"message":"`+err.Error()+`"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(mr)
+}
+
+func (p *GitLabPlugin) getMergeRequest(w http.ResponseWriter, r *http.Request) {
+	path, err := projectPathParam(r)
+	if err != nil {
+		http.Error(w, `{"message":"invalid project id"}`, http.StatusBadRequest)
+		return
+	}
+	iid, err := strconv.Atoi(r.PathValue("iid"))
+	if err != nil {
+		http.Error(w, `{"message":"invalid merge request iid"}`, http.StatusBadRequest)
+		return
+	}
+	mr, ok := p.store.GetMergeRequest(path, iid)
+	if !ok {
+		http.Error(w, `{"message":"404 Not found"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mr)
+}
+
+type createNoteRequest struct {
+	Body string `json:"body"`
+}
+
+func (p *GitLabPlugin) createNote(w http.ResponseWriter, r *http.Request) {
+	path, err := projectPathParam(r)
+	if err != nil {
+		http.Error(w, `{"message":"invalid project id"}`, http.StatusBadRequest)
+		return
+	}
+	iid, err := strconv.Atoi(r.PathValue("iid"))
+	if err != nil {
+		http.Error(w, `{"message":"invalid merge request iid"}`, http.StatusBadRequest)
+		return
+	}
+	var req createNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"message":"invalid JSON"}`, http.StatusBadRequest)
+		return
+	}
+	note, err := p.store.AddNote(path, iid, req.Body)
+	if err != nil {
+		http.Error(w, `{"message":"`+err.Error()+`"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(note)
+}
+
+func (p *GitLabPlugin) listNotes(w http.ResponseWriter, r *http.Request) {
+	path, err := projectPathParam(r)
+	if err != nil {
+		http.Error(w, `{"message":"invalid project id"}`, http.StatusBadRequest)
+		return
+	}
+	iid, err := strconv.Atoi(r.PathValue("iid"))
+	if err != nil {
+		http.Error(w, `{"message":"invalid merge request iid"}`, http.StatusBadRequest)
+		return
+	}
+	notes := p.store.ListNotes(path, iid)
+	if notes == nil {
+		notes = []*Note{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notes)
+}
@@ -0,0 +1,202 @@
+package gitlab
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/islo-labs/double-agent/internal/forge"
+)
+
+// Project is a GitLab project (GitLab's name for a repository).
+type Project struct {
+	forge.Repo
+	PathWithNamespace string `json:"path_with_namespace"`
+}
+
+// MergeRequest is a GitLab merge request.
+type MergeRequest struct {
+	forge.Change
+	IID int `json:"iid"`
+}
+
+// Note is a single entry in a merge request's discussion thread.
+type Note struct {
+	ID        int       `json:"id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store holds in-memory state for a GitLab plugin instance.
+type Store struct {
+	mu       sync.RWMutex
+	projects map[string]*Project        // key: "namespace/project"
+	issues   map[string][]*forge.Issue  // key: project path
+	mrs      map[string][]*MergeRequest // key: project path
+	notes    map[string][]*Note         // key: "project path#iid"
+	ids      *forge.IDAllocator
+	issueCtr map[string]int
+	mrCtr    map[string]int
+}
+
+// NewStore creates an empty Store.
+func NewStore() *Store {
+	return &Store{
+		projects: make(map[string]*Project),
+		issues:   make(map[string][]*forge.Issue),
+		mrs:      make(map[string][]*MergeRequest),
+		notes:    make(map[string][]*Note),
+		ids:      forge.NewIDAllocator(),
+		issueCtr: make(map[string]int),
+		mrCtr:    make(map[string]int),
+	}
+}
+
+// Reset clears all state.
+func (s *Store) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.projects = make(map[string]*Project)
+	s.issues = make(map[string][]*forge.Issue)
+	s.mrs = make(map[string][]*MergeRequest)
+	s.notes = make(map[string][]*Note)
+	s.ids.Reset()
+	s.issueCtr = make(map[string]int)
+	s.mrCtr = make(map[string]int)
+}
+
+func projectPath(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// CreateProject creates a new project.
+func (s *Store) CreateProject(namespace, name string, private bool) (*Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := projectPath(namespace, name)
+	if _, exists := s.projects[path]; exists {
+		return nil, fmt.Errorf("project %s already exists", path)
+	}
+	now := time.Now().UTC()
+	p := &Project{
+		Repo: forge.Repo{
+			ID:        s.ids.Next(),
+			Namespace: namespace,
+			Name:      name,
+			FullName:  path,
+			Private:   private,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		PathWithNamespace: path,
+	}
+	s.projects[path] = p
+	return p, nil
+}
+
+// GetProject returns a project by its "namespace/project" path.
+func (s *Store) GetProject(path string) (*Project, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.projects[path]
+	return p, ok
+}
+
+// CreateIssue creates a new issue on a project.
+func (s *Store) CreateIssue(path, title, body string) (*forge.Issue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.projects[path]; !ok {
+		return nil, fmt.Errorf("project %s not found", path)
+	}
+	s.issueCtr[path]++
+	now := time.Now().UTC()
+	issue := &forge.Issue{
+		ID:        s.ids.Next(),
+		Number:    s.issueCtr[path],
+		Title:     title,
+		Body:      body,
+		State:     "opened",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.issues[path] = append(s.issues[path], issue)
+	return issue, nil
+}
+
+// ListIssues returns all issues for a project.
+func (s *Store) ListIssues(path string) []*forge.Issue {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.issues[path]
+}
+
+// CreateMergeRequest creates a new merge request on a project.
+func (s *Store) CreateMergeRequest(path, title, body, sourceBranch, targetBranch string) (*MergeRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.projects[path]; !ok {
+		return nil, fmt.Errorf("project %s not found", path)
+	}
+	s.mrCtr[path]++
+	now := time.Now().UTC()
+	mr := &MergeRequest{
+		Change: forge.Change{
+			ID:        s.ids.Next(),
+			Number:    s.mrCtr[path],
+			Title:     title,
+			Body:      body,
+			State:     "opened",
+			Head:      sourceBranch,
+			Base:      targetBranch,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		IID: s.mrCtr[path],
+	}
+	s.mrs[path] = append(s.mrs[path], mr)
+	return mr, nil
+}
+
+// GetMergeRequest returns a merge request by its project-scoped iid.
+func (s *Store) GetMergeRequest(path string, iid int) (*MergeRequest, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, mr := range s.mrs[path] {
+		if mr.IID == iid {
+			return mr, true
+		}
+	}
+	return nil, false
+}
+
+func noteKey(path string, iid int) string {
+	return fmt.Sprintf("%s#%d", path, iid)
+}
+
+// AddNote appends a note to a merge request's discussion thread.
+func (s *Store) AddNote(path string, iid int, body string) (*Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := false
+	for _, mr := range s.mrs[path] {
+		if mr.IID == iid {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("merge request %s!%d not found", path, iid)
+	}
+	note := &Note{ID: s.ids.Next(), Body: body, CreatedAt: time.Now().UTC()}
+	key := noteKey(path, iid)
+	s.notes[key] = append(s.notes[key], note)
+	return note, nil
+}
+
+// ListNotes returns all notes on a merge request's discussion thread.
+func (s *Store) ListNotes(path string, iid int) []*Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.notes[noteKey(path, iid)]
+}
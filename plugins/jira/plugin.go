@@ -4,15 +4,24 @@ package jira
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/islo-labs/double-agent/pkg/sdk"
+	"github.com/islo-labs/double-agent/pkg/sdk/auth"
 )
 
+// webhookEnvPrefix matches internal/engine's convention of folding
+// per-event webhook targets into the plugin's env.
+const webhookEnvPrefix = "WEBHOOK_URL_"
+
 // JiraPlugin is a fake Jira API service.
 type JiraPlugin struct {
 	store      *Store
 	router     *http.ServeMux
 	projectKey string
+
+	webhookURLs map[string]string // event -> URL
+	notify      func(sdk.Notification)
 }
 
 // New creates a new JiraPlugin.
@@ -26,15 +35,68 @@ func (p *JiraPlugin) Info() sdk.PluginInfo {
 	return sdk.PluginInfo{Name: "jira", Version: "v1"}
 }
 
+func (p *JiraPlugin) Manifest() sdk.PluginManifest {
+	return sdk.PluginManifest{Name: "jira", Version: "v1", APIVersion: sdk.APIVersion}
+}
+
 func (p *JiraPlugin) Configure(env map[string]string) error {
 	if key, ok := env["PROJECT_KEY"]; ok {
 		p.projectKey = key
 		// Pre-create the default project.
 		p.store.CreateProject(key, key)
 	}
+	for k, v := range env {
+		if event, ok := strings.CutPrefix(k, webhookEnvPrefix); ok {
+			if p.webhookURLs == nil {
+				p.webhookURLs = make(map[string]string)
+			}
+			p.webhookURLs[event] = v
+		}
+	}
 	return nil
 }
 
+// SetNotifyFunc implements sdk.Notifier.
+func (p *JiraPlugin) SetNotifyFunc(fn func(sdk.Notification)) {
+	p.notify = fn
+}
+
+// emitWebhook pushes a "webhook" notification for event if a target URL is
+// configured for it and the host has registered a notify callback.
+func (p *JiraPlugin) emitWebhook(event string, payload any) {
+	if p.notify == nil {
+		return
+	}
+	url, ok := p.webhookURLs[event]
+	if !ok {
+		return
+	}
+	body, err := json.Marshal(map[string]any{
+		"webhookEvent": event,
+		"issue":        payload,
+	})
+	if err != nil {
+		return
+	}
+	params, err := json.Marshal(sdk.WebhookParams{
+		Event: event,
+		URL:   url,
+		Body:  string(body),
+	})
+	if err != nil {
+		return
+	}
+	p.notify(sdk.Notification{Method: "webhook", Params: params})
+}
+
+// authorizedForScope reports whether the request may proceed: either no
+// auth.Middleware ran for this service (no Principal on the context), or
+// it did and the resolved Principal carries scope.
+func authorizedForScope(r *http.Request, scope string) bool {
+	principal, ok := auth.FromContext(r.Context())
+	return !ok || principal.HasScope(scope)
+}
+
 func (p *JiraPlugin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	p.router.ServeHTTP(w, r)
 }
@@ -48,6 +110,16 @@ func (p *JiraPlugin) Reset() error {
 	return nil
 }
 
+// Snapshot implements sdk.Snapshotter.
+func (p *JiraPlugin) Snapshot() (json.RawMessage, error) {
+	return p.store.Snapshot()
+}
+
+// Restore implements sdk.Snapshotter.
+func (p *JiraPlugin) Restore(data json.RawMessage) error {
+	return p.store.Restore(data)
+}
+
 func (p *JiraPlugin) setupRoutes() {
 	p.router = http.NewServeMux()
 	p.router.HandleFunc("POST /rest/api/2/project", p.createProject)
@@ -55,6 +127,7 @@ func (p *JiraPlugin) setupRoutes() {
 	p.router.HandleFunc("GET /rest/api/2/project/{key}", p.getProject)
 	p.router.HandleFunc("POST /rest/api/2/issue", p.createIssue)
 	p.router.HandleFunc("GET /rest/api/2/issue/{key}", p.getIssue)
+	p.router.HandleFunc("POST /rest/api/2/issue/{key}/transitions", p.transitionIssue)
 	p.router.HandleFunc("GET /rest/api/2/search", p.searchIssues)
 }
 
@@ -64,6 +137,10 @@ type createProjectRequest struct {
 }
 
 func (p *JiraPlugin) createProject(w http.ResponseWriter, r *http.Request) {
+	if !authorizedForScope(r, "ADMINISTER") {
+		http.Error(w, `{"errorMessages":["You do not have permission to administer projects"]}`, http.StatusForbidden)
+		return
+	}
 	var req createProjectRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, `{"errorMessages":["invalid JSON"]}`, http.StatusBadRequest)
@@ -139,6 +216,7 @@ func (p *JiraPlugin) createIssue(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(issue)
+	p.emitWebhook("jira:issue_created", issue)
 }
 
 func (p *JiraPlugin) getIssue(w http.ResponseWriter, r *http.Request) {
@@ -152,6 +230,34 @@ func (p *JiraPlugin) getIssue(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(issue)
 }
 
+type transitionIssueRequest struct {
+	Transition struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"transition"`
+}
+
+func (p *JiraPlugin) transitionIssue(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	var req transitionIssueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"errorMessages":["invalid JSON"]}`, http.StatusBadRequest)
+		return
+	}
+	status := req.Transition.Name
+	if status == "" {
+		http.Error(w, `{"errorMessages":["transition name is required"]}`, http.StatusBadRequest)
+		return
+	}
+	issue, err := p.store.TransitionIssue(key, status)
+	if err != nil {
+		http.Error(w, `{"errorMessages":["`+err.Error()+`"]}`, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	p.emitWebhook("jira:issue_updated", issue)
+}
+
 func (p *JiraPlugin) searchIssues(w http.ResponseWriter, r *http.Request) {
 	// Simple search: return all issues for the default project.
 	projectKey := r.URL.Query().Get("jql")
@@ -1,6 +1,7 @@
 package jira
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
@@ -135,9 +136,73 @@ func (s *Store) GetIssue(issueKey string) (*Issue, bool) {
 	return nil, false
 }
 
+// TransitionIssue moves an issue to a new status by key.
+func (s *Store) TransitionIssue(issueKey, status string) (*Issue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, issues := range s.issues {
+		for _, issue := range issues {
+			if issue.Key == issueKey {
+				issue.Status = status
+				issue.UpdatedAt = time.Now().UTC()
+				return issue, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("issue %s not found", issueKey)
+}
+
 // ListIssues returns all issues for a project.
 func (s *Store) ListIssues(projectKey string) []*Issue {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.issues[projectKey]
 }
+
+// storeSnapshot is the serializable form of a Store's state.
+type storeSnapshot struct {
+	Projects map[string]*Project `json:"projects"`
+	Issues   map[string][]*Issue `json:"issues"`
+	NextID   int                 `json:"next_id"`
+	IssueCtr map[string]int      `json:"issue_ctr"`
+}
+
+// Snapshot implements sdk.Snapshotter.
+func (s *Store) Snapshot() (json.RawMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return json.Marshal(storeSnapshot{
+		Projects: s.projects,
+		Issues:   s.issues,
+		NextID:   s.nextID,
+		IssueCtr: s.issueCtr,
+	})
+}
+
+// Restore implements sdk.Snapshotter, replacing the store's state wholesale.
+func (s *Store) Restore(data json.RawMessage) error {
+	var snap storeSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("unmarshaling snapshot: %w", err)
+	}
+	if snap.Projects == nil {
+		snap.Projects = make(map[string]*Project)
+	}
+	if snap.Issues == nil {
+		snap.Issues = make(map[string][]*Issue)
+	}
+	if snap.IssueCtr == nil {
+		snap.IssueCtr = make(map[string]int)
+	}
+	if snap.NextID < 1 {
+		snap.NextID = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.projects = snap.Projects
+	s.issues = snap.Issues
+	s.issueCtr = snap.IssueCtr
+	s.nextID = snap.NextID
+	return nil
+}
@@ -0,0 +1,206 @@
+// Package sourcehut provides a fake git.sr.ht / todo.sr.ht API plugin.
+package sourcehut
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/islo-labs/double-agent/pkg/sdk"
+)
+
+// pageSize is the number of results returned per page; callers page
+// through the rest using the "next" cursor, matching the real sr.ht APIs.
+const pageSize = 20
+
+// SourcehutPlugin is a fake Sourcehut API service.
+type SourcehutPlugin struct {
+	store        *Store
+	router       *http.ServeMux
+	defaultOwner string
+}
+
+// New creates a new SourcehutPlugin.
+func New() sdk.Plugin {
+	p := &SourcehutPlugin{store: NewStore()}
+	p.setupRoutes()
+	return p
+}
+
+func (p *SourcehutPlugin) Info() sdk.PluginInfo {
+	return sdk.PluginInfo{Name: "sourcehut", Version: "v1"}
+}
+
+func (p *SourcehutPlugin) Manifest() sdk.PluginManifest {
+	return sdk.PluginManifest{Name: "sourcehut", Version: "v1", APIVersion: sdk.APIVersion}
+}
+
+func (p *SourcehutPlugin) Configure(env map[string]string) error {
+	if owner, ok := env["DEFAULT_OWNER"]; ok {
+		p.defaultOwner = owner
+	}
+	return nil
+}
+
+func (p *SourcehutPlugin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.router.ServeHTTP(w, r)
+}
+
+func (p *SourcehutPlugin) Reset() error {
+	p.store.Reset()
+	return nil
+}
+
+func (p *SourcehutPlugin) setupRoutes() {
+	p.router = http.NewServeMux()
+	p.router.HandleFunc("POST /api/repos", p.createRepo)
+	p.router.HandleFunc("GET /api/repos", p.listRepos)
+	p.router.HandleFunc("GET /api/repos/{owner}/{name}", p.getRepo)
+	p.router.HandleFunc("POST /api/trackers", p.createTracker)
+	p.router.HandleFunc("POST /api/trackers/{owner}/{name}/tickets", p.createTicket)
+	p.router.HandleFunc("GET /api/trackers/{owner}/{name}/tickets", p.listTickets)
+}
+
+// page returns results[offset:offset+pageSize] along with the cursor the
+// caller should pass as "?cursor=" to fetch the next page, or 0 once
+// there are no more results, mirroring the sr.ht "next" pagination field.
+func page[T any](results []T, cursor int) (pageResults []T, next int) {
+	if cursor < 0 || cursor > len(results) {
+		cursor = 0
+	}
+	end := cursor + pageSize
+	if end >= len(results) {
+		return results[cursor:], 0
+	}
+	return results[cursor:end], end
+}
+
+func cursorParam(r *http.Request) int {
+	c, err := strconv.Atoi(r.URL.Query().Get("cursor"))
+	if err != nil {
+		return 0
+	}
+	return c
+}
+
+type repoResponse struct {
+	Results []*Repo `json:"results"`
+	Next    int     `json:"next"`
+}
+
+type createRepoRequest struct {
+	Owner string `json:"owner"`
+	Name  string `json:"name"`
+}
+
+func (p *SourcehutPlugin) createRepo(w http.ResponseWriter, r *http.Request) {
+	var req createRepoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"errors":[{"reason":"invalid JSON"}]}`, http.StatusBadRequest)
+		return
+	}
+	if req.Owner == "" {
+		req.Owner = p.defaultOwner
+	}
+	if req.Owner == "" || req.Name == "" {
+		http.Error(w, `{"errors":[{"reason":"owner and name are required"}]}`, http.StatusUnprocessableEntity)
+		return
+	}
+	repo, err := p.store.CreateRepo(req.Owner, req.Name)
+	if err != nil {
+		http.Error(w, `{"errors":[{"reason":"`+err.Error()+`"}]}`, http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(repo)
+}
+
+func (p *SourcehutPlugin) listRepos(w http.ResponseWriter, r *http.Request) {
+	owner := r.URL.Query().Get("owner")
+	if owner == "" {
+		owner = p.defaultOwner
+	}
+	repos, next := page(p.store.ListRepos(owner), cursorParam(r))
+	if repos == nil {
+		repos = []*Repo{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(repoResponse{Results: repos, Next: next})
+}
+
+func (p *SourcehutPlugin) getRepo(w http.ResponseWriter, r *http.Request) {
+	key := ownedKey(r.PathValue("owner"), r.PathValue("name"))
+	repo, ok := p.store.GetRepo(key)
+	if !ok {
+		http.Error(w, `{"errors":[{"reason":"repo not found"}]}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(repo)
+}
+
+type createTrackerRequest struct {
+	Owner string `json:"owner"`
+	Name  string `json:"name"`
+}
+
+func (p *SourcehutPlugin) createTracker(w http.ResponseWriter, r *http.Request) {
+	var req createTrackerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"errors":[{"reason":"invalid JSON"}]}`, http.StatusBadRequest)
+		return
+	}
+	if req.Owner == "" {
+		req.Owner = p.defaultOwner
+	}
+	if req.Owner == "" || req.Name == "" {
+		http.Error(w, `{"errors":[{"reason":"owner and name are required"}]}`, http.StatusUnprocessableEntity)
+		return
+	}
+	tracker, err := p.store.CreateTracker(req.Owner, req.Name)
+	if err != nil {
+		http.Error(w, `{"errors":[{"reason":"`+err.Error()+`"}]}`, http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tracker)
+}
+
+type createTicketRequest struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+type ticketResponse struct {
+	Results []*Ticket `json:"results"`
+	Next    int       `json:"next"`
+}
+
+func (p *SourcehutPlugin) createTicket(w http.ResponseWriter, r *http.Request) {
+	key := ownedKey(r.PathValue("owner"), r.PathValue("name"))
+	var req createTicketRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"errors":[{"reason":"invalid JSON"}]}`, http.StatusBadRequest)
+		return
+	}
+	ticket, err := p.store.CreateTicket(key, req.Subject, req.Body)
+	if err != nil {
+		http.Error(w, `{"errors":[{"reason":"`+err.Error()+`"}]}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ticket)
+}
+
+func (p *SourcehutPlugin) listTickets(w http.ResponseWriter, r *http.Request) {
+	key := ownedKey(r.PathValue("owner"), r.PathValue("name"))
+	tickets, next := page(p.store.ListTickets(key), cursorParam(r))
+	if tickets == nil {
+		tickets = []*Ticket{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ticketResponse{Results: tickets, Next: next})
+}
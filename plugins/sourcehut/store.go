@@ -0,0 +1,156 @@
+package sourcehut
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/islo-labs/double-agent/internal/forge"
+)
+
+// Repo is a git.sr.ht repository, owned by a "~user".
+type Repo struct {
+	forge.Repo
+}
+
+// Tracker is a todo.sr.ht issue tracker, owned by a "~user".
+type Tracker struct {
+	Owner string `json:"owner"`
+	Name  string `json:"name"`
+}
+
+// Ticket is a todo.sr.ht tracker ticket.
+type Ticket struct {
+	forge.Issue
+}
+
+// Store holds in-memory state for a Sourcehut plugin instance.
+type Store struct {
+	mu        sync.RWMutex
+	repos     map[string]*Repo     // key: "~user/repo"
+	trackers  map[string]*Tracker  // key: "~user/tracker"
+	tickets   map[string][]*Ticket // key: "~user/tracker"
+	ids       *forge.IDAllocator
+	ticketCtr map[string]int
+}
+
+// NewStore creates an empty Store.
+func NewStore() *Store {
+	return &Store{
+		repos:     make(map[string]*Repo),
+		trackers:  make(map[string]*Tracker),
+		tickets:   make(map[string][]*Ticket),
+		ids:       forge.NewIDAllocator(),
+		ticketCtr: make(map[string]int),
+	}
+}
+
+// Reset clears all state.
+func (s *Store) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repos = make(map[string]*Repo)
+	s.trackers = make(map[string]*Tracker)
+	s.tickets = make(map[string][]*Ticket)
+	s.ids.Reset()
+	s.ticketCtr = make(map[string]int)
+}
+
+func ownedKey(owner, name string) string {
+	return owner + "/" + name
+}
+
+// CreateRepo creates a new git.sr.ht repository under owner.
+func (s *Store) CreateRepo(owner, name string) (*Repo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := ownedKey(owner, name)
+	if _, exists := s.repos[key]; exists {
+		return nil, fmt.Errorf("repository %s already exists", key)
+	}
+	now := time.Now().UTC()
+	r := &Repo{Repo: forge.Repo{
+		ID:        s.ids.Next(),
+		Namespace: owner,
+		Name:      name,
+		FullName:  key,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+	s.repos[key] = r
+	return r, nil
+}
+
+// GetRepo returns a repository by "~user/repo" key.
+func (s *Store) GetRepo(key string) (*Repo, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.repos[key]
+	return r, ok
+}
+
+// ListRepos returns every repository owned by owner, in creation order.
+func (s *Store) ListRepos(owner string) []*Repo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*Repo
+	for _, r := range s.repos {
+		if r.Namespace == owner {
+			out = append(out, r)
+		}
+	}
+	// IDs are allocated sequentially, so ID order is creation order.
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// CreateTracker creates a new todo.sr.ht tracker under owner.
+func (s *Store) CreateTracker(owner, name string) (*Tracker, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := ownedKey(owner, name)
+	if _, exists := s.trackers[key]; exists {
+		return nil, fmt.Errorf("tracker %s already exists", key)
+	}
+	t := &Tracker{Owner: owner, Name: name}
+	s.trackers[key] = t
+	return t, nil
+}
+
+// GetTracker returns a tracker by "~user/tracker" key.
+func (s *Store) GetTracker(key string) (*Tracker, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.trackers[key]
+	return t, ok
+}
+
+// CreateTicket creates a new ticket on a tracker.
+func (s *Store) CreateTicket(trackerKey, subject, body string) (*Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.trackers[trackerKey]; !ok {
+		return nil, fmt.Errorf("tracker %s not found", trackerKey)
+	}
+	s.ticketCtr[trackerKey]++
+	now := time.Now().UTC()
+	ticket := &Ticket{Issue: forge.Issue{
+		ID:        s.ids.Next(),
+		Number:    s.ticketCtr[trackerKey],
+		Title:     subject,
+		Body:      body,
+		State:     "REPORTED",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+	s.tickets[trackerKey] = append(s.tickets[trackerKey], ticket)
+	return ticket, nil
+}
+
+// ListTickets returns every ticket on a tracker, in creation order.
+func (s *Store) ListTickets(trackerKey string) []*Ticket {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tickets[trackerKey]
+}
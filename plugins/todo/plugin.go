@@ -25,6 +25,10 @@ func (p *TodoPlugin) Info() sdk.PluginInfo {
 	return sdk.PluginInfo{Name: "todo", Version: "v1"}
 }
 
+func (p *TodoPlugin) Manifest() sdk.PluginManifest {
+	return sdk.PluginManifest{Name: "todo", Version: "v1", APIVersion: sdk.APIVersion}
+}
+
 func (p *TodoPlugin) Configure(env map[string]string) error {
 	return nil
 }
@@ -38,6 +42,16 @@ func (p *TodoPlugin) Reset() error {
 	return nil
 }
 
+// Snapshot implements sdk.Snapshotter.
+func (p *TodoPlugin) Snapshot() (json.RawMessage, error) {
+	return p.store.Snapshot()
+}
+
+// Restore implements sdk.Snapshotter.
+func (p *TodoPlugin) Restore(data json.RawMessage) error {
+	return p.store.Restore(data)
+}
+
 func (p *TodoPlugin) setupRoutes() {
 	p.router = http.NewServeMux()
 	p.router.HandleFunc("POST /todos", p.createTodo)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
@@ -104,3 +105,36 @@ func (s *Store) Delete(id int) error {
 	delete(s.todos, id)
 	return nil
 }
+
+// storeSnapshot is the serializable form of a Store's state.
+type storeSnapshot struct {
+	Todos  map[int]*Todo `json:"todos"`
+	NextID int           `json:"next_id"`
+}
+
+// Snapshot implements sdk.Snapshotter.
+func (s *Store) Snapshot() (json.RawMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return json.Marshal(storeSnapshot{Todos: s.todos, NextID: s.nextID})
+}
+
+// Restore implements sdk.Snapshotter, replacing the store's state wholesale.
+func (s *Store) Restore(data json.RawMessage) error {
+	var snap storeSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("unmarshaling snapshot: %w", err)
+	}
+	if snap.Todos == nil {
+		snap.Todos = make(map[int]*Todo)
+	}
+	if snap.NextID < 1 {
+		snap.NextID = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.todos = snap.Todos
+	s.nextID = snap.NextID
+	return nil
+}